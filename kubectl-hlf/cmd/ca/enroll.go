@@ -46,6 +46,9 @@ type enrollCmd struct {
 }
 
 func (c *enrollCmd) validate() error {
+	if c.enrollOpts.Type == "idemix" && c.fileOutput == "" {
+		return errors.New("--output is required for idemix enrollments")
+	}
 	return c.enrollOpts.Validate()
 }
 func (c *enrollCmd) run(args []string) error {
@@ -71,6 +74,24 @@ func (c *enrollCmd) run(args []string) error {
 		}
 	}
 	log.Debugf("CA URL=%s", url)
+	if c.enrollOpts.Type == "idemix" {
+		enrollment, err := enrollIdemix(idemixEnrollRequest{
+			TLSCert: certAuth.Status.TlsCert,
+			URL:     url,
+			Name:    c.enrollOpts.CAName,
+			User:    c.enrollOpts.User,
+			Secret:  c.enrollOpts.Secret,
+		})
+		if err != nil {
+			return err
+		}
+		err = writeIdemixMSP(c.fileOutput, enrollment)
+		if err != nil {
+			return err
+		}
+		log.Infof("Idemix MSP for %s written to %s", enrollment.SignerConfig.EnrollmentId, c.fileOutput)
+		return nil
+	}
 	var attributes []*api.AttributeRequest
 	if len(c.enrollOpts.Attributes) > 0 {
 		attributeList := strings.Split(c.enrollOpts.Attributes, ",")
@@ -167,7 +188,7 @@ func newCAEnrollCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	f.StringVarP(&c.enrollOpts.CAName, "ca-name", "", "", "CA name to enroll this user")
 	f.StringVarP(&c.enrollOpts.User, "user", "", "", "Name for the new user")
 	f.StringVarP(&c.enrollOpts.Secret, "secret", "", "", "Secret for the new user")
-	f.StringVarP(&c.enrollOpts.Type, "type", "", "", "Type of the identity to create (peer/client/orderer/admin)")
+	f.StringVarP(&c.enrollOpts.Type, "type", "", "", "Type of the identity to create (peer/client/orderer/admin), use idemix to obtain an idemix credential")
 	f.StringVarP(&c.enrollOpts.MspID, "mspid", "", "", "MSP ID of the organization")
 	f.StringVarP(&c.enrollOpts.Profile, "profile", "", "", "Profile")
 	f.StringVarP(&c.enrollOpts.CN, "cn", "", "", "cn")
@@ -177,7 +198,7 @@ func newCAEnrollCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	f.StringVarP(&c.enrollOpts.Attributes, "attributes", "", "", "Attributes of the user")
 	f.StringVarP(&c.enrollOpts.CAURL, "ca-url", "", "", "Fabric CA URL")
 
-	f.StringVar(&c.fileOutput, "output", "", "output file")
+	f.StringVar(&c.fileOutput, "output", "", "output file, or output directory of the MSP for idemix enrollments")
 
 	return cmd
 }
@@ -0,0 +1,192 @@
+package ca
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	idemixbccsp "github.com/IBM/idemix/bccsp"
+	"github.com/IBM/idemix/bccsp/keystore"
+	bccsp "github.com/IBM/idemix/bccsp/schemes"
+	idemix "github.com/IBM/idemix/bccsp/schemes/dlog/crypto"
+	"github.com/IBM/idemix/bccsp/schemes/dlog/crypto/translator/amcl"
+	math "github.com/IBM/mathlib"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+)
+
+// idemixAttributeNames are the attributes the Fabric CA includes in every idemix credential
+var idemixAttributeNames = []string{"OU", "Role", "EnrollmentID", "RevocationHandle"}
+
+type idemixCredentialRequest struct {
+	Request *idemix.CredRequest `json:"request"`
+	CAName  string              `json:"caname,omitempty"`
+}
+
+type idemixCredentialAttrs struct {
+	OU               string `json:"OU"`
+	Role             int32  `json:"Role"`
+	EnrollmentID     string `json:"EnrollmentID"`
+	RevocationHandle string `json:"RevocationHandle"`
+}
+
+type idemixCredentialResponse struct {
+	Nonce      []byte                `json:"Nonce"`
+	Credential []byte                `json:"Credential"`
+	CRI        []byte                `json:"CRI"`
+	Attrs      idemixCredentialAttrs `json:"Attrs"`
+	CAInfo     helpers.IdemixCAInfo  `json:"CAInfo"`
+}
+
+type idemixEnrollment struct {
+	IssuerPublicKey           []byte
+	IssuerRevocationPublicKey []byte
+	SignerConfig              *msp.IdemixMSPSignerConfig
+}
+
+type idemixEnrollRequest struct {
+	TLSCert string
+	URL     string
+	Name    string
+	User    string
+	Secret  string
+}
+
+func postIdemixCredential(client *http.Client, request idemixEnrollRequest, body idemixCredentialRequest) (*idemixCredentialResponse, error) {
+	reqBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/v1/idemix/credential", strings.TrimSuffix(request.URL, "/"))
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(request.User, request.Secret)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	resBytes, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	caResponse := &struct {
+		Success bool                     `json:"success"`
+		Result  idemixCredentialResponse `json:"result"`
+		Errors  []struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	err = json.Unmarshal(resBytes, caResponse)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse response from %s: %s", url, string(resBytes))
+	}
+	if !caResponse.Success {
+		var messages []string
+		for _, caErr := range caResponse.Errors {
+			messages = append(messages, fmt.Sprintf("%d: %s", caErr.Code, caErr.Message))
+		}
+		return nil, errors.Errorf("idemix enrollment failed: %s", strings.Join(messages, ", "))
+	}
+	return &caResponse.Result, nil
+}
+
+// enrollIdemix obtains an idemix credential from the Fabric CA, first requesting a nonce and then
+// sending a credential request signed with a freshly generated user secret key
+func enrollIdemix(request idemixEnrollRequest) (*idemixEnrollment, error) {
+	client, err := helpers.NewCAHTTPClient(request.TLSCert)
+	if err != nil {
+		return nil, err
+	}
+	nonceResponse, err := postIdemixCredential(client, request, idemixCredentialRequest{CAName: request.Name})
+	if err != nil {
+		return nil, err
+	}
+	curve := math.Curves[math.FP256BN_AMCL]
+	csp, err := idemixbccsp.New(&keystore.Dummy{}, curve, &amcl.Fp256bn{C: curve}, true)
+	if err != nil {
+		return nil, err
+	}
+	issuerPublicKey, err := csp.KeyImport(
+		nonceResponse.CAInfo.IssuerPublicKey,
+		&bccsp.IdemixIssuerPublicKeyImportOpts{Temporary: true, AttributeNames: idemixAttributeNames},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to import the issuer public key")
+	}
+	userKey, err := csp.KeyGen(&bccsp.IdemixUserSecretKeyGenOpts{Temporary: true})
+	if err != nil {
+		return nil, err
+	}
+	credRequestBytes, err := csp.Sign(userKey, nil, &bccsp.IdemixCredentialRequestSignerOpts{
+		IssuerPK:    issuerPublicKey,
+		IssuerNonce: nonceResponse.Nonce,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the credential request")
+	}
+	credRequest := &idemix.CredRequest{}
+	err = proto.Unmarshal(credRequestBytes, credRequest)
+	if err != nil {
+		return nil, err
+	}
+	credResponse, err := postIdemixCredential(client, request, idemixCredentialRequest{
+		Request: credRequest,
+		CAName:  request.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sk, err := userKey.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &idemixEnrollment{
+		IssuerPublicKey:           nonceResponse.CAInfo.IssuerPublicKey,
+		IssuerRevocationPublicKey: nonceResponse.CAInfo.IssuerRevocationPublicKey,
+		SignerConfig: &msp.IdemixMSPSignerConfig{
+			Cred:                            credResponse.Credential,
+			Sk:                              sk,
+			OrganizationalUnitIdentifier:    credResponse.Attrs.OU,
+			Role:                            credResponse.Attrs.Role,
+			EnrollmentId:                    credResponse.Attrs.EnrollmentID,
+			CredentialRevocationInformation: credResponse.CRI,
+		},
+	}, nil
+}
+
+// writeIdemixMSP writes the idemix MSP directory layout expected by the Fabric SDKs and the peer CLI
+func writeIdemixMSP(outputPath string, enrollment *idemixEnrollment) error {
+	mspPath := filepath.Join(outputPath, "msp")
+	userPath := filepath.Join(outputPath, "user")
+	for _, dir := range []string{mspPath, userPath} {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+	}
+	err := ioutil.WriteFile(filepath.Join(mspPath, "IssuerPublicKey"), enrollment.IssuerPublicKey, 0644)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(mspPath, "RevocationPublicKey"), enrollment.IssuerRevocationPublicKey, 0644)
+	if err != nil {
+		return err
+	}
+	signerConfig, err := proto.Marshal(enrollment.SignerConfig)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(userPath, "SignerConfig"), signerConfig, 0600)
+}
@@ -0,0 +1,66 @@
+package acl
+
+import (
+	"io"
+	"strings"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// checkPolicyRef verifies that the policy referenced by an ACL exists in the channel, relative references
+// are resolved against the application group like the peer does
+func checkPolicyRef(cftxGen *configtx.ConfigTx, policyRef string) error {
+	if !strings.HasPrefix(policyRef, "/") {
+		policyRef = "/Channel/Application/" + policyRef
+	}
+	parts := strings.Split(strings.TrimPrefix(policyRef, "/"), "/")
+	if len(parts) < 2 || parts[0] != "Channel" {
+		return errors.Errorf("invalid policy reference %s", policyRef)
+	}
+	var policies map[string]configtx.Policy
+	var err error
+	switch len(parts) {
+	case 2:
+		policies, err = cftxGen.Channel().Policies()
+	case 3:
+		switch parts[1] {
+		case "Application":
+			policies, err = cftxGen.Application().Policies()
+		case "Orderer":
+			policies, err = cftxGen.Orderer().Policies()
+		default:
+			return errors.Errorf("invalid policy reference %s", policyRef)
+		}
+	case 4:
+		if parts[1] != "Application" {
+			return errors.Errorf("invalid policy reference %s", policyRef)
+		}
+		org := cftxGen.Application().Organization(parts[2])
+		if org == nil {
+			return errors.Errorf("organization %s of policy reference %s not found", parts[2], policyRef)
+		}
+		policies, err = org.Policies()
+	default:
+		return errors.Errorf("invalid policy reference %s", policyRef)
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := policies[parts[len(parts)-1]]; !ok {
+		return errors.Errorf("policy %s not found in the channel", policyRef)
+	}
+	return nil
+}
+
+func NewACLCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	aclCmd := &cobra.Command{
+		Use: "acl",
+	}
+	aclCmd.AddCommand(
+		newGetACLCMD(stdOut, stdErr),
+		newSetACLCMD(stdOut, stdErr),
+	)
+	return aclCmd
+}
@@ -0,0 +1,109 @@
+package acl
+
+import (
+	"io"
+	"os"
+	"sort"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type getACLCmd struct {
+	configPath  string
+	channelName string
+	userName    string
+	mspID       string
+	resources   []string
+}
+
+func (c *getACLCmd) validate() error {
+	return nil
+}
+
+func (c *getACLCmd) run() error {
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	cfg, err := helpers.GetCurrentConfigFromPeer(resClient, c.channelName)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfg)
+	acls, err := cftxGen.Application().ACLs()
+	if err != nil {
+		return err
+	}
+	resources := c.resources
+	if len(resources) == 0 {
+		for resource := range acls {
+			resources = append(resources, resource)
+		}
+		sort.Strings(resources)
+	}
+	var data [][]string
+	for _, resource := range resources {
+		policyRef, ok := acls[resource]
+		if !ok {
+			return errors.Errorf("no ACL defined for resource %s in channel %s", resource, c.channelName)
+		}
+		data = append(data, []string{resource, policyRef})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Resource", "Policy"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
+
+func newGetACLCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &getACLCmd{}
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Get the ACLs of a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization")
+	persistentFlags.StringSliceVarP(&c.resources, "resource", "", []string{}, "Only show these resources, e.g. qscc/GetBlockByNumber")
+	cmd.MarkPersistentFlagRequired("channel")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	return cmd
+}
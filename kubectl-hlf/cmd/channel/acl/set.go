@@ -0,0 +1,200 @@
+package acl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type setACLCmd struct {
+	configPath  string
+	channelName string
+	userName    string
+	mspID       string
+	acls        []string
+	output      string
+	mainChannel string
+}
+
+func (c *setACLCmd) validate() error {
+	if len(c.acls) == 0 {
+		return errors.Errorf("--acl is required")
+	}
+	if c.output != "" && c.mainChannel != "" {
+		return errors.Errorf("--output and --mainchannel can't be used together")
+	}
+	return nil
+}
+
+// parseACLs parses the ACLs in the format <resource>=<policy reference>
+func (c *setACLCmd) parseACLs() (map[string]string, error) {
+	acls := map[string]string{}
+	for _, acl := range c.acls {
+		parts := strings.SplitN(acl, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("invalid ACL %s, expected <resource>=<policy>", acl)
+		}
+		acls[parts[0]] = parts[1]
+	}
+	return acls, nil
+}
+
+func (c *setACLCmd) run() error {
+	targetACLs, err := c.parseACLs()
+	if err != nil {
+		return err
+	}
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	cfg, err := helpers.GetCurrentConfigFromPeer(resClient, c.channelName)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfg)
+	acls, err := cftxGen.Application().ACLs()
+	if err != nil {
+		return err
+	}
+	if acls == nil {
+		acls = map[string]string{}
+	}
+	var resources []string
+	for resource := range targetACLs {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	changes := 0
+	for _, resource := range resources {
+		policyRef := targetACLs[resource]
+		err = checkPolicyRef(&cftxGen, policyRef)
+		if err != nil {
+			return err
+		}
+		currentPolicyRef, exists := acls[resource]
+		if exists && currentPolicyRef == policyRef {
+			continue
+		}
+		if exists {
+			fmt.Printf("- %s %s\n", resource, currentPolicyRef)
+		}
+		fmt.Printf("+ %s %s\n", resource, policyRef)
+		acls[resource] = policyRef
+		changes++
+	}
+	if changes == 0 {
+		log.Infof("ACLs are up to date")
+		return nil
+	}
+	if c.mainChannel != "" {
+		return c.updateMainChannel(acls)
+	}
+	err = cftxGen.Application().SetACLs(acls)
+	if err != nil {
+		return err
+	}
+	channelConfigBytes, err := helpers.ComputeConfigUpdateEnvelope(&cftxGen, c.channelName)
+	if err != nil {
+		return err
+	}
+	if c.output != "" {
+		err = ioutil.WriteFile(c.output, channelConfigBytes, 0644)
+		if err != nil {
+			return err
+		}
+		log.Infof("output file: %s", c.output)
+		return nil
+	}
+	chResponse, err := resClient.SaveChannel(resmgmt.SaveChannelRequest{
+		ChannelID:     c.channelName,
+		ChannelConfig: bytes.NewReader(channelConfigBytes),
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("ACLs updated: %s", chResponse.TransactionID)
+	return nil
+}
+
+// updateMainChannel sets all the ACLs of the channel in the FabricMainChannel, since the operator
+// replaces the ACLs of the application group with the ones in the spec
+func (c *setACLCmd) updateMainChannel(acls map[string]string) error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	fabricMainChannel, err := oclient.HlfV1alpha1().FabricMainChannels().Get(ctx, c.mainChannel, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if fabricMainChannel.Spec.Name != c.channelName {
+		return errors.Errorf("main channel %s manages channel %s, not %s", c.mainChannel, fabricMainChannel.Spec.Name, c.channelName)
+	}
+	if fabricMainChannel.Spec.ChannelConfig == nil {
+		fabricMainChannel.Spec.ChannelConfig = &v1alpha1.FabricMainChannelConfig{}
+	}
+	if fabricMainChannel.Spec.ChannelConfig.Application == nil {
+		fabricMainChannel.Spec.ChannelConfig.Application = &v1alpha1.FabricMainChannelApplicationConfig{}
+	}
+	fabricMainChannel.Spec.ChannelConfig.Application.ACLs = &acls
+	_, err = oclient.HlfV1alpha1().FabricMainChannels().Update(ctx, fabricMainChannel, v1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("MainChannel %s updated", fabricMainChannel.Name)
+	return nil
+}
+
+func newSetACLCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &setACLCmd{}
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set the ACLs of a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization")
+	persistentFlags.StringSliceVarP(&c.acls, "acl", "", []string{}, "ACLs in the format <resource>=<policy>, e.g. qscc/GetBlockByNumber=/Channel/Application/Readers")
+	persistentFlags.StringVarP(&c.output, "output", "o", "", "Write the config update to this file instead of submitting it")
+	persistentFlags.StringVarP(&c.mainChannel, "mainchannel", "", "", "Apply the change through this FabricMainChannel instead of submitting it")
+	cmd.MarkPersistentFlagRequired("channel")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	cmd.MarkPersistentFlagRequired("acl")
+	return cmd
+}
@@ -0,0 +1,17 @@
+package anchorpeers
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func NewAnchorPeersCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	anchorPeersCmd := &cobra.Command{
+		Use: "anchorpeers",
+	}
+	anchorPeersCmd.AddCommand(
+		newSetAnchorPeersCMD(stdOut, stdErr),
+	)
+	return anchorPeersCmd
+}
@@ -0,0 +1,230 @@
+package anchorpeers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type setAnchorPeersCmd struct {
+	configPath  string
+	channelName string
+	userName    string
+	mspID       string
+	peers       []string
+	output      string
+	dryRun      bool
+}
+
+func (c *setAnchorPeersCmd) validate() error {
+	if len(c.peers) == 0 {
+		return errors.Errorf("--peers is required")
+	}
+	return nil
+}
+
+func addressKey(address configtx.Address) string {
+	return fmt.Sprintf("%s:%d", address.Host, address.Port)
+}
+
+// getTargetAnchorPeers resolves the anchor peers, either peers in the cluster referenced by <name>.<namespace>
+// or external peers referenced by <host>:<port>
+func (c *setAnchorPeersCmd) getTargetAnchorPeers() ([]configtx.Address, error) {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return nil, err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	var addresses []configtx.Address
+	for _, peerName := range c.peers {
+		if strings.Contains(peerName, ":") {
+			host, portStr, err := net.SplitHostPort(peerName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid external anchor peer %s", peerName)
+			}
+			port, err := strconv.Atoi(portStr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid port of the external anchor peer %s", peerName)
+			}
+			addresses = append(addresses, configtx.Address{Host: host, Port: port})
+			continue
+		}
+		peer, err := helpers.GetPeerByFullName(clientSet, oclient, peerName)
+		if err != nil {
+			return nil, err
+		}
+		if peer.Spec.MspID != c.mspID {
+			return nil, errors.Errorf("peer %s belongs to %s, not to %s", peerName, peer.Spec.MspID, c.mspID)
+		}
+		peerHostName, peerPort, err := helpers.GetPeerHostAndPort(clientSet, peer.Spec, peer.Status)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, configtx.Address{Host: peerHostName, Port: peerPort})
+	}
+	return addresses, nil
+}
+
+func (c *setAnchorPeersCmd) run() error {
+	targetAnchorPeers, err := c.getTargetAnchorPeers()
+	if err != nil {
+		return err
+	}
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	block, err := resClient.QueryConfigBlockFromOrderer(c.channelName)
+	if err != nil {
+		return err
+	}
+	cfgBlock, err := resource.ExtractConfigFromBlock(block)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfgBlock)
+	app := cftxGen.Application().Organization(c.mspID)
+	if app == nil {
+		return errors.Errorf("organization %s not found in the application group of channel %s", c.mspID, c.channelName)
+	}
+	currentAnchorPeers, err := app.AnchorPeers()
+	if err != nil {
+		return err
+	}
+	current := map[string]configtx.Address{}
+	for _, anchorPeer := range currentAnchorPeers {
+		current[addressKey(anchorPeer)] = anchorPeer
+	}
+	target := map[string]configtx.Address{}
+	for _, anchorPeer := range targetAnchorPeers {
+		target[addressKey(anchorPeer)] = anchorPeer
+	}
+	var keys []string
+	for key := range current {
+		keys = append(keys, key)
+	}
+	for key := range target {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	changes := 0
+	fmt.Printf("Anchor peers of %s in channel %s:\n", c.mspID, c.channelName)
+	for _, key := range keys {
+		_, inCurrent := current[key]
+		_, inTarget := target[key]
+		switch {
+		case inCurrent && inTarget:
+			fmt.Printf("  %s\n", key)
+		case inCurrent:
+			fmt.Printf("- %s\n", key)
+			changes++
+			err = app.RemoveAnchorPeer(current[key])
+			if err != nil {
+				return err
+			}
+		case inTarget:
+			fmt.Printf("+ %s\n", key)
+			changes++
+			err = app.AddAnchorPeer(target[key])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if changes == 0 {
+		log.Infof("Anchor peers are up to date")
+		return nil
+	}
+	if c.dryRun {
+		return nil
+	}
+	configUpdateBytes, err := cftxGen.ComputeMarshaledUpdate(c.channelName)
+	if err != nil {
+		return err
+	}
+	configUpdate := &common.ConfigUpdate{}
+	err = proto.Unmarshal(configUpdateBytes, configUpdate)
+	if err != nil {
+		return err
+	}
+	channelConfigBytes, err := helpers.CreateConfigUpdateEnvelope(c.channelName, configUpdate)
+	if err != nil {
+		return err
+	}
+	if c.output != "" {
+		err = ioutil.WriteFile(c.output, channelConfigBytes, 0644)
+		if err != nil {
+			return err
+		}
+		log.Infof("output file: %s", c.output)
+		return nil
+	}
+	chResponse, err := resClient.SaveChannel(resmgmt.SaveChannelRequest{
+		ChannelID:     c.channelName,
+		ChannelConfig: bytes.NewReader(channelConfigBytes),
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("anchor peers updated: %s", chResponse.TransactionID)
+	return nil
+}
+
+func newSetAnchorPeersCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &setAnchorPeersCmd{}
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set the anchor peers of an organization in a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization")
+	persistentFlags.StringSliceVarP(&c.peers, "peers", "", []string{}, "Anchor peers, either peers in the cluster (peer0.default) or external peers (peer0.org1.com:443)")
+	persistentFlags.StringVarP(&c.output, "output", "o", "", "Write the config update to this file instead of submitting it")
+	persistentFlags.BoolVarP(&c.dryRun, "dry-run", "", false, "Only show the changes to the anchor peers")
+	cmd.MarkPersistentFlagRequired("channel")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	cmd.MarkPersistentFlagRequired("peers")
+	return cmd
+}
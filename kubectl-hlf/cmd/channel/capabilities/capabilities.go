@@ -0,0 +1,97 @@
+package capabilities
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	groupChannel     = "channel"
+	groupOrderer     = "orderer"
+	groupApplication = "application"
+)
+
+type fabricVersion [3]int
+
+func (v fabricVersion) less(other fabricVersion) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+	return false
+}
+
+func (v fabricVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// minVersions is the minimum Fabric release that understands each capability, per config group
+var minVersions = map[string]map[string]fabricVersion{
+	groupChannel: {
+		"V1_1":   {1, 1, 0},
+		"V1_3":   {1, 3, 0},
+		"V1_4_2": {1, 4, 2},
+		"V1_4_3": {1, 4, 3},
+		"V2_0":   {2, 0, 0},
+		"V3_0":   {3, 0, 0},
+	},
+	groupOrderer: {
+		"V1_1":   {1, 1, 0},
+		"V1_4_2": {1, 4, 2},
+		"V2_0":   {2, 0, 0},
+	},
+	groupApplication: {
+		"V1_1":   {1, 1, 0},
+		"V1_2":   {1, 2, 0},
+		"V1_3":   {1, 3, 0},
+		"V1_4_2": {1, 4, 2},
+		"V2_0":   {2, 0, 0},
+		"V2_5":   {2, 5, 0},
+	},
+}
+
+func getMinVersion(group string, capability string) (fabricVersion, error) {
+	version, ok := minVersions[group][capability]
+	if !ok {
+		return fabricVersion{}, errors.Errorf("unknown %s capability %s", group, capability)
+	}
+	return version, nil
+}
+
+var tagVersionRegex = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// parseTagVersion extracts the Fabric version from image tags such as 2.5.4, v2.5.4 or amd64-2.5.4
+func parseTagVersion(tag string) (fabricVersion, error) {
+	match := tagVersionRegex.FindStringSubmatch(tag)
+	if match == nil {
+		return fabricVersion{}, errors.Errorf("can't parse the Fabric version of tag %s", tag)
+	}
+	var version fabricVersion
+	for i := 0; i < 3; i++ {
+		if match[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return fabricVersion{}, err
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+func NewCapabilitiesCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	capabilitiesCmd := &cobra.Command{
+		Use: "capabilities",
+	}
+	capabilitiesCmd.AddCommand(
+		newUpgradeCapabilitiesCMD(stdOut, stdErr),
+	)
+	return capabilitiesCmd
+}
@@ -0,0 +1,357 @@
+package capabilities
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+type capabilityGroup interface {
+	Capabilities() ([]string, error)
+	AddCapability(capability string) error
+	RemoveCapability(capability string) error
+}
+
+type nodeVersion struct {
+	kind    string
+	name    string
+	tag     string
+	version fabricVersion
+	err     error
+}
+
+type upgradeCapabilitiesCmd struct {
+	configPath         string
+	channelName        string
+	userName           string
+	mspID              string
+	applicationCap     string
+	ordererCap         string
+	channelCap         string
+	output             string
+	targetCapabilities map[string]string
+}
+
+func (c *upgradeCapabilitiesCmd) validate() error {
+	c.targetCapabilities = map[string]string{}
+	if c.channelCap != "" {
+		c.targetCapabilities[groupChannel] = c.channelCap
+	}
+	if c.ordererCap != "" {
+		c.targetCapabilities[groupOrderer] = c.ordererCap
+	}
+	if c.applicationCap != "" {
+		c.targetCapabilities[groupApplication] = c.applicationCap
+	}
+	if len(c.targetCapabilities) == 0 {
+		return errors.Errorf("at least one of --application, --orderer or --channel-capability is required")
+	}
+	for group, capability := range c.targetCapabilities {
+		if _, err := getMinVersion(group, capability); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getCapabilityGroup(cftxGen *configtx.ConfigTx, group string) capabilityGroup {
+	switch group {
+	case groupChannel:
+		return cftxGen.Channel()
+	case groupOrderer:
+		return cftxGen.Orderer()
+	default:
+		return cftxGen.Application()
+	}
+}
+
+// getPeerVersions returns the versions of the peers in the cluster that belong to the application
+// organizations of the channel
+func (c *upgradeCapabilitiesCmd) getPeerVersions(clientSet *kubernetes.Clientset, cftxGen *configtx.ConfigTx) ([]nodeVersion, error) {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return nil, err
+	}
+	appConfig, err := cftxGen.Application().Configuration()
+	if err != nil {
+		return nil, err
+	}
+	var mspIDs []string
+	for _, org := range appConfig.Organizations {
+		mspIDs = append(mspIDs, org.Name)
+	}
+	_, peers, err := helpers.GetClusterPeers(clientSet, oclient, "")
+	if err != nil {
+		return nil, err
+	}
+	var versions []nodeVersion
+	for _, peer := range peers {
+		if !utils.Contains(mspIDs, peer.Spec.MspID) {
+			continue
+		}
+		version, err := parseTagVersion(peer.Spec.Tag)
+		versions = append(versions, nodeVersion{
+			kind:    "peer",
+			name:    fmt.Sprintf("%s.%s", peer.Object.Name, peer.Object.Namespace),
+			tag:     peer.Spec.Tag,
+			version: version,
+			err:     err,
+		})
+	}
+	return versions, nil
+}
+
+// getOrdererVersions returns the versions of the orderer nodes in the cluster that are consenters of the channel,
+// consenters that aren't managed in this cluster can't be checked
+func (c *upgradeCapabilitiesCmd) getOrdererVersions(clientSet *kubernetes.Clientset, cftxGen *configtx.ConfigTx) ([]nodeVersion, error) {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return nil, err
+	}
+	ordConfig, err := cftxGen.Orderer().Configuration()
+	if err != nil {
+		return nil, err
+	}
+	ordNodes, err := helpers.GetClusterOrdererNodes(clientSet, oclient, "")
+	if err != nil {
+		return nil, err
+	}
+	var versions []nodeVersion
+	for _, consenter := range ordConfig.EtcdRaft.Consenters {
+		found := false
+		for _, ordNode := range ordNodes {
+			host, port, err := helpers.GetOrdererHostAndPort(clientSet, ordNode.Spec, ordNode.Status)
+			if err != nil || host != consenter.Address.Host || port != consenter.Address.Port {
+				continue
+			}
+			found = true
+			version, err := parseTagVersion(ordNode.Spec.Tag)
+			versions = append(versions, nodeVersion{
+				kind:    "orderer",
+				name:    fmt.Sprintf("%s.%s", ordNode.Name, ordNode.Namespace),
+				tag:     ordNode.Spec.Tag,
+				version: version,
+				err:     err,
+			})
+			break
+		}
+		if !found {
+			log.Warnf("Consenter %s:%d is not managed in this cluster, its version can't be checked", consenter.Address.Host, consenter.Address.Port)
+		}
+	}
+	return versions, nil
+}
+
+// checkNodeVersions verifies that every node runs a release that supports the target capabilities,
+// application capabilities are checked against peers, orderer capabilities against orderers and
+// channel capabilities against both
+func (c *upgradeCapabilitiesCmd) checkNodeVersions(peers []nodeVersion, orderers []nodeVersion) error {
+	var data [][]string
+	incompatible := 0
+	check := func(node nodeVersion, groups []string) {
+		var problems []string
+		if node.err != nil {
+			problems = append(problems, node.err.Error())
+		} else {
+			for _, group := range groups {
+				capability, ok := c.targetCapabilities[group]
+				if !ok {
+					continue
+				}
+				minVersion, _ := getMinVersion(group, capability)
+				if node.version.less(minVersion) {
+					problems = append(problems, fmt.Sprintf("%s capability %s requires %s", group, capability, minVersion))
+				}
+			}
+		}
+		status := "OK"
+		if len(problems) > 0 {
+			incompatible++
+			status = strings.Join(problems, ", ")
+		}
+		data = append(data, []string{node.kind, node.name, node.tag, status})
+	}
+	for _, peer := range peers {
+		check(peer, []string{groupChannel, groupApplication})
+	}
+	for _, orderer := range orderers {
+		check(orderer, []string{groupChannel, groupOrderer})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Kind", "Name", "Tag", "Status"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	if incompatible > 0 {
+		return errors.Errorf("%d nodes don't support the target capabilities, upgrade them first", incompatible)
+	}
+	return nil
+}
+
+func (c *upgradeCapabilitiesCmd) run() error {
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	cfg, err := helpers.GetCurrentConfigFromPeer(resClient, c.channelName)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfg)
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	var peers, orderers []nodeVersion
+	_, upgradeChannel := c.targetCapabilities[groupChannel]
+	if _, ok := c.targetCapabilities[groupApplication]; ok || upgradeChannel {
+		peers, err = c.getPeerVersions(clientSet, &cftxGen)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := c.targetCapabilities[groupOrderer]; ok || upgradeChannel {
+		err = helpers.CheckOrdererNotInMaintenance(&cftxGen, c.channelName)
+		if err != nil {
+			return err
+		}
+		orderers, err = c.getOrdererVersions(clientSet, &cftxGen)
+		if err != nil {
+			return err
+		}
+	}
+	err = c.checkNodeVersions(peers, orderers)
+	if err != nil {
+		return err
+	}
+	changes := 0
+	for _, group := range []string{groupChannel, groupOrderer, groupApplication} {
+		capability, ok := c.targetCapabilities[group]
+		if !ok {
+			continue
+		}
+		capGroup := getCapabilityGroup(&cftxGen, group)
+		currentCapabilities, err := capGroup.Capabilities()
+		if err != nil {
+			return err
+		}
+		targetVersion, _ := getMinVersion(group, capability)
+		upToDate := false
+		for _, currentCapability := range currentCapabilities {
+			if currentCapability == capability {
+				upToDate = len(currentCapabilities) == 1
+				continue
+			}
+			currentVersion, err := getMinVersion(group, currentCapability)
+			if err == nil && targetVersion.less(currentVersion) {
+				return errors.Errorf("%s capability %s is newer than %s, capabilities can't be downgraded", group, currentCapability, capability)
+			}
+		}
+		if upToDate {
+			log.Infof("The %s capability is already %s", group, capability)
+			continue
+		}
+		fmt.Printf("%s: %s -> %s\n", group, strings.Join(currentCapabilities, ","), capability)
+		err = capGroup.AddCapability(capability)
+		if err != nil {
+			return err
+		}
+		for _, currentCapability := range currentCapabilities {
+			if currentCapability == capability {
+				continue
+			}
+			err = capGroup.RemoveCapability(currentCapability)
+			if err != nil {
+				return err
+			}
+		}
+		changes++
+	}
+	if changes == 0 {
+		log.Infof("Capabilities are up to date")
+		return nil
+	}
+	channelConfigBytes, err := helpers.ComputeConfigUpdateEnvelope(&cftxGen, c.channelName)
+	if err != nil {
+		return err
+	}
+	if c.output != "" {
+		err = ioutil.WriteFile(c.output, channelConfigBytes, 0644)
+		if err != nil {
+			return err
+		}
+		log.Infof("output file: %s", c.output)
+		return nil
+	}
+	chResponse, err := resClient.SaveChannel(resmgmt.SaveChannelRequest{
+		ChannelID:     c.channelName,
+		ChannelConfig: bytes.NewReader(channelConfigBytes),
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("capabilities upgraded: %s", chResponse.TransactionID)
+	return nil
+}
+
+func newUpgradeCapabilitiesCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &upgradeCapabilitiesCmd{}
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade the capabilities of a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization")
+	persistentFlags.StringVarP(&c.applicationCap, "application", "", "", "Target application capability, e.g. V2_5")
+	persistentFlags.StringVarP(&c.ordererCap, "orderer", "", "", "Target orderer capability, e.g. V2_0")
+	persistentFlags.StringVarP(&c.channelCap, "channel-capability", "", "", "Target channel capability, e.g. V2_0")
+	persistentFlags.StringVarP(&c.output, "output", "o", "", "Write the config update to this file instead of submitting it")
+	cmd.MarkPersistentFlagRequired("channel")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	return cmd
+}
@@ -3,8 +3,14 @@ package channel
 import (
 	"io"
 
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channel/acl"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channel/anchorpeers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channel/capabilities"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channel/consenter"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channel/maintenance"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channel/ordererparams"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channel/ordorg"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channel/policy"
 
 	"github.com/spf13/cobra"
 )
@@ -24,9 +30,16 @@ func NewChannelCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
 		newTopChannelCMD(stdOut, stdErr),
 		newSignUpdateChannelCMD(stdOut, stdErr),
 		newAddOrgToChannelCMD(stdOut, stdErr),
+		newRemoveOrgFromChannelCMD(stdOut, stdErr),
 		ordorg.NewOrdOrgCmd(stdOut, stdErr),
 		consenter.NewConsenterCmd(stdOut, stdErr),
 		newDelAnchorPeerCMD(stdOut, stdErr),
+		anchorpeers.NewAnchorPeersCmd(stdOut, stdErr),
+		policy.NewPolicyCmd(stdOut, stdErr),
+		acl.NewACLCmd(stdOut, stdErr),
+		capabilities.NewCapabilitiesCmd(stdOut, stdErr),
+		ordererparams.NewOrdererParamsCmd(stdOut, stdErr),
+		maintenance.NewMaintenanceCmd(stdOut, stdErr),
 	)
 	return channelCmd
 }
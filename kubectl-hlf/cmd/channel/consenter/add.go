@@ -62,6 +62,9 @@ func (c *addConsenterCmd) run() error {
 		return err
 	}
 	cftxGen := configtx.New(cfgBlock)
+	if err := helpers.CheckOrdererNotInMaintenance(&cftxGen, c.channelName); err != nil {
+		return err
+	}
 	cfgOrd := cftxGen.Orderer()
 	for _, ordNodeName := range c.ordNodeNames {
 		ordNode, err := helpers.GetOrdererNodeByFullName(clientSet, oClient, ordNodeName)
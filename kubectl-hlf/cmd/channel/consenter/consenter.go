@@ -14,6 +14,7 @@ func NewConsenterCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
 		newAddConsenterCMD(stdOut, stdErr),
 		newDelConsenterCMD(stdOut, stdErr),
 		newReplaceConsenterCMD(stdOut, stdErr),
+		newRotateConsenterCMD(stdOut, stdErr),
 	)
 	return consenterCmd
 }
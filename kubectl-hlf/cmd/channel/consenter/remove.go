@@ -60,6 +60,9 @@ func (c *delConsenterCmd) run() error {
 		return err
 	}
 	cftxGen := configtx.New(cfgBlock)
+	if err := helpers.CheckOrdererNotInMaintenance(&cftxGen, c.channelName); err != nil {
+		return err
+	}
 	cfgOrd := cftxGen.Orderer()
 	ordNode, err := helpers.GetOrdererNodeByFullName(clientSet, oClient, c.ordNodeName)
 	if err != nil {
@@ -62,6 +62,9 @@ func (c *replaceonsenterCmd) run() error {
 		return err
 	}
 	cftxGen := configtx.New(cfgBlock)
+	if err := helpers.CheckOrdererNotInMaintenance(&cftxGen, c.channelName); err != nil {
+		return err
+	}
 	cfgOrd := cftxGen.Orderer()
 	ordConf, err := cftxGen.Orderer().Configuration()
 	ordNode, err := helpers.GetOrdererNodeByFullName(clientSet, oClient, c.ordNodeName)
@@ -0,0 +1,562 @@
+package consenter
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-config/configtx/orderer"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/osnadmin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// rotation steps, in the order they're executed for every channel
+const (
+	rotateStepPending  = "pending"
+	rotateStepJoined   = "joined"
+	rotateStepCaughtUp = "caughtup"
+	rotateStepAdded    = "added"
+	rotateStepActive   = "active"
+	rotateStepRemoved  = "removed"
+	rotateStepDone     = "done"
+)
+
+type identity struct {
+	Cert Pem `json:"cert"`
+	Key  Pem `json:"key"`
+}
+type Pem struct {
+	Pem string
+}
+
+type rotateState struct {
+	OldOrderer string            `json:"oldOrderer"`
+	NewOrderer string            `json:"newOrderer"`
+	Channels   map[string]string `json:"channels"`
+}
+
+type rotateNode struct {
+	node   *helpers.ClusterOrdererNode
+	osnURL string
+}
+
+type rotateConsenterCmd struct {
+	configPath    string
+	userName      string
+	mspID         string
+	identity      string
+	oldOrderer    string
+	newOrderer    string
+	channels      []string
+	stateFile     string
+	resume        bool
+	timeout       time.Duration
+	clientSet     *kubernetes.Clientset
+	certPool      *x509.CertPool
+	tlsClientCert tls.Certificate
+	orderers      []*rotateNode
+}
+
+func (c *rotateConsenterCmd) validate() error {
+	if c.oldOrderer == "" {
+		return errors.Errorf("--old-orderer is required")
+	}
+	if c.newOrderer == "" {
+		return errors.Errorf("--new-orderer is required")
+	}
+	if c.oldOrderer == c.newOrderer {
+		return errors.Errorf("--old-orderer and --new-orderer must be different")
+	}
+	return nil
+}
+
+func (c *rotateConsenterCmd) getStateFile() string {
+	if c.stateFile != "" {
+		return c.stateFile
+	}
+	return fmt.Sprintf("rotate-%s-%s.json", c.oldOrderer, c.newOrderer)
+}
+
+func (c *rotateConsenterCmd) saveState(state *rotateState) error {
+	stateBytes, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.getStateFile(), stateBytes, 0644)
+}
+
+func (c *rotateConsenterCmd) run() error {
+	oClient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	c.clientSet = clientSet
+	identityBytes, err := ioutil.ReadFile(c.identity)
+	if err != nil {
+		return err
+	}
+	id := &identity{}
+	err = yaml.Unmarshal(identityBytes, id)
+	if err != nil {
+		return err
+	}
+	c.tlsClientCert, err = tls.X509KeyPair(
+		[]byte(id.Cert.Pem),
+		[]byte(id.Key.Pem),
+	)
+	if err != nil {
+		return err
+	}
+	c.certPool = x509.NewCertPool()
+	ordNodes, err := helpers.GetClusterOrdererNodes(clientSet, oClient, "")
+	if err != nil {
+		return err
+	}
+	var oldNode *rotateNode
+	var newNode *rotateNode
+	for _, ordNode := range ordNodes {
+		c.certPool.AppendCertsFromPEM([]byte(ordNode.Status.TlsCert))
+		c.certPool.AppendCertsFromPEM([]byte(ordNode.Status.TlsAdminCert))
+		node := &rotateNode{
+			node:   ordNode,
+			osnURL: ordNode.AdminURL,
+		}
+		c.orderers = append(c.orderers, node)
+		switch ordNode.Name {
+		case c.oldOrderer:
+			oldNode = node
+		case c.newOrderer:
+			newNode = node
+		}
+	}
+	if oldNode == nil {
+		return errors.Errorf("Orderer Node with name=%s not found", c.oldOrderer)
+	}
+	if newNode == nil {
+		return errors.Errorf("Orderer Node with name=%s not found", c.newOrderer)
+	}
+
+	state := &rotateState{
+		OldOrderer: c.oldOrderer,
+		NewOrderer: c.newOrderer,
+		Channels:   map[string]string{},
+	}
+	if c.resume {
+		stateBytes, err := ioutil.ReadFile(c.getStateFile())
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the rotation state from %s", c.getStateFile())
+		}
+		err = json.Unmarshal(stateBytes, state)
+		if err != nil {
+			return err
+		}
+		if state.OldOrderer != c.oldOrderer || state.NewOrderer != c.newOrderer {
+			return errors.Errorf(
+				"rotation state in %s replaces %s by %s, not %s by %s",
+				c.getStateFile(),
+				state.OldOrderer,
+				state.NewOrderer,
+				c.oldOrderer,
+				c.newOrderer,
+			)
+		}
+	} else {
+		if _, err := os.Stat(c.getStateFile()); err == nil {
+			return errors.Errorf("rotation state %s already exists, run with --resume to continue it or remove it to start over", c.getStateFile())
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		channels := c.channels
+		if len(channels) == 0 {
+			channels, err = c.listChannels(oldNode)
+			if err != nil {
+				return err
+			}
+		}
+		for _, channel := range channels {
+			state.Channels[channel] = rotateStepPending
+		}
+	}
+	err = c.saveState(state)
+	if err != nil {
+		return err
+	}
+
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	var channelIDs []string
+	for channelID := range state.Channels {
+		channelIDs = append(channelIDs, channelID)
+	}
+	sort.Strings(channelIDs)
+	for _, channelID := range channelIDs {
+		step := state.Channels[channelID]
+		for step != rotateStepDone {
+			log.Infof("Channel %s: running step after %s", channelID, step)
+			step, err = c.runStep(resClient, channelID, step, oldNode, newNode)
+			if err != nil {
+				return errors.Wrapf(err, "rotation of channel %s failed, fix the issue and run again with --resume", channelID)
+			}
+			state.Channels[channelID] = step
+			err = c.saveState(state)
+			if err != nil {
+				return err
+			}
+		}
+		log.Infof("Channel %s: consenter %s replaced by %s", channelID, c.oldOrderer, c.newOrderer)
+	}
+	err = os.Remove(c.getStateFile())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// runStep executes the step following the given one and returns the step reached
+func (c *rotateConsenterCmd) runStep(resClient *resmgmt.Client, channelID string, step string, oldNode *rotateNode, newNode *rotateNode) (string, error) {
+	switch step {
+	case rotateStepPending:
+		chInfo, err := c.channelInfo(newNode, channelID)
+		if err == nil && chInfo != nil {
+			log.Infof("Orderer %s already joined to %s", c.newOrderer, channelID)
+			return rotateStepJoined, nil
+		}
+		block, err := resClient.QueryConfigBlockFromOrderer(channelID)
+		if err != nil {
+			return step, err
+		}
+		blockBytes, err := proto.Marshal(block)
+		if err != nil {
+			return step, err
+		}
+		chResponse, err := osnadmin.Join(newNode.osnURL, blockBytes, c.certPool, c.tlsClientCert)
+		if err != nil {
+			return step, err
+		}
+		defer chResponse.Body.Close()
+		if chResponse.StatusCode != 201 {
+			return step, errors.Errorf("error joining %s as follower, got status code=%d", c.newOrderer, chResponse.StatusCode)
+		}
+		return rotateStepJoined, nil
+	case rotateStepJoined:
+		err := c.waitFor(fmt.Sprintf("%s to catch up on %s", c.newOrderer, channelID), func() (bool, error) {
+			newInfo, err := c.channelInfo(newNode, channelID)
+			if err != nil {
+				return false, err
+			}
+			oldInfo, err := c.channelInfo(oldNode, channelID)
+			if err != nil {
+				return false, err
+			}
+			log.Debugf("%s height=%d status=%s, %s height=%d", c.newOrderer, newInfo.Height, newInfo.Status, c.oldOrderer, oldInfo.Height)
+			return newInfo.Status == osnadmin.StatusActive && newInfo.Height >= oldInfo.Height, nil
+		})
+		if err != nil {
+			return step, err
+		}
+		return rotateStepCaughtUp, nil
+	case rotateStepCaughtUp:
+		err := c.updateConsenters(resClient, channelID, func(cfgOrd *configtx.OrdererGroup, consenters []orderer.Consenter) (bool, error) {
+			tlsCert, err := utils.ParseX509Certificate([]byte(newNode.node.Status.TlsCert))
+			if err != nil {
+				return false, err
+			}
+			for _, consenter := range consenters {
+				if consenter.ServerTLSCert != nil && bytes.Equal(consenter.ServerTLSCert.Raw, tlsCert.Raw) {
+					return false, nil
+				}
+			}
+			hostPort, err := helpers.GetOrdererHostPort(c.clientSet, newNode.node.Item)
+			if err != nil {
+				return false, err
+			}
+			err = cfgOrd.AddConsenter(orderer.Consenter{
+				Address: orderer.EtcdAddress{
+					Host: hostPort.Host,
+					Port: hostPort.Port,
+				},
+				ClientTLSCert: tlsCert,
+				ServerTLSCert: tlsCert,
+			})
+			return err == nil, err
+		})
+		if err != nil {
+			return step, err
+		}
+		return rotateStepAdded, nil
+	case rotateStepAdded:
+		err := c.waitFor(fmt.Sprintf("%s to become an active consenter of %s", c.newOrderer, channelID), func() (bool, error) {
+			newInfo, err := c.channelInfo(newNode, channelID)
+			if err != nil {
+				return false, err
+			}
+			return newInfo.ConsensusRelation == osnadmin.ConsensusRelationConsenter && newInfo.Status == osnadmin.StatusActive, nil
+		})
+		if err != nil {
+			return step, err
+		}
+		return rotateStepActive, nil
+	case rotateStepActive:
+		err := c.updateConsenters(resClient, channelID, func(cfgOrd *configtx.OrdererGroup, consenters []orderer.Consenter) (bool, error) {
+			tlsCert, err := utils.ParseX509Certificate([]byte(oldNode.node.Status.TlsCert))
+			if err != nil {
+				return false, err
+			}
+			for idx, consenter := range consenters {
+				if consenter.ServerTLSCert != nil && bytes.Equal(consenter.ServerTLSCert.Raw, tlsCert.Raw) {
+					remaining := append(append([]orderer.Consenter{}, consenters[:idx]...), consenters[idx+1:]...)
+					err = c.checkQuorum(channelID, remaining)
+					if err != nil {
+						return false, err
+					}
+					err = cfgOrd.RemoveConsenter(consenter)
+					return err == nil, err
+				}
+			}
+			log.Infof("Orderer %s is not a consenter of %s anymore", c.oldOrderer, channelID)
+			return false, nil
+		})
+		if err != nil {
+			return step, err
+		}
+		return rotateStepRemoved, nil
+	case rotateStepRemoved:
+		chResponse, err := osnadmin.Remove(oldNode.osnURL, channelID, c.certPool, c.tlsClientCert)
+		if err != nil {
+			return step, err
+		}
+		defer chResponse.Body.Close()
+		if chResponse.StatusCode != 204 && chResponse.StatusCode != 404 {
+			return step, errors.Errorf("error removing %s from the channel, got status code=%d", c.oldOrderer, chResponse.StatusCode)
+		}
+		return rotateStepDone, nil
+	}
+	return step, errors.Errorf("unknown rotation step %s", step)
+}
+
+// updateConsenters computes and submits a config update modifying the consenters of the channel
+func (c *rotateConsenterCmd) updateConsenters(
+	resClient *resmgmt.Client,
+	channelID string,
+	modify func(cfgOrd *configtx.OrdererGroup, consenters []orderer.Consenter) (bool, error),
+) error {
+	block, err := resClient.QueryConfigBlockFromOrderer(channelID)
+	if err != nil {
+		return err
+	}
+	cfgBlock, err := resource.ExtractConfigFromBlock(block)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfgBlock)
+	if err := helpers.CheckOrdererNotInMaintenance(&cftxGen, channelID); err != nil {
+		return err
+	}
+	cfgOrd := cftxGen.Orderer()
+	ordConf, err := cfgOrd.Configuration()
+	if err != nil {
+		return err
+	}
+	changed, err := modify(cfgOrd, ordConf.EtcdRaft.Consenters)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	configUpdateBytes, err := cftxGen.ComputeMarshaledUpdate(channelID)
+	if err != nil {
+		return err
+	}
+	configUpdate := &common.ConfigUpdate{}
+	err = proto.Unmarshal(configUpdateBytes, configUpdate)
+	if err != nil {
+		return err
+	}
+	channelConfigBytes, err := helpers.CreateConfigUpdateEnvelope(channelID, configUpdate)
+	if err != nil {
+		return err
+	}
+	chResponse, err := resClient.SaveChannel(
+		resmgmt.SaveChannelRequest{
+			ChannelID:     channelID,
+			ChannelConfig: bytes.NewReader(channelConfigBytes),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	log.Infof("Channel %s updated: %s", channelID, chResponse.TransactionID)
+	return nil
+}
+
+// checkQuorum checks that a majority of the consenters that remain after removing the old orderer
+// are active on the channel, otherwise the removal would leave the channel without a raft quorum
+func (c *rotateConsenterCmd) checkQuorum(channelID string, remaining []orderer.Consenter) error {
+	active := 0
+	for _, consenter := range remaining {
+		node := c.findOrderer(consenter)
+		if node == nil {
+			log.Warnf("Consenter %s:%d of %s isn't an orderer node of the cluster, considering it inactive", consenter.Address.Host, consenter.Address.Port, channelID)
+			continue
+		}
+		chInfo, err := c.channelInfo(node, channelID)
+		if err != nil {
+			log.Warnf("Couldn't get the status of %s on %s: %v", node.node.Name, channelID, err)
+			continue
+		}
+		if chInfo.ConsensusRelation == osnadmin.ConsensusRelationConsenter && chInfo.Status == osnadmin.StatusActive {
+			active++
+		}
+	}
+	if active <= len(remaining)/2 {
+		return errors.Errorf(
+			"only %d of the %d remaining consenters of %s are active, removing %s would lose the raft quorum",
+			active,
+			len(remaining),
+			channelID,
+			c.oldOrderer,
+		)
+	}
+	return nil
+}
+
+// findOrderer returns the orderer node of the cluster with the TLS certificate of the consenter
+func (c *rotateConsenterCmd) findOrderer(consenter orderer.Consenter) *rotateNode {
+	if consenter.ServerTLSCert == nil {
+		return nil
+	}
+	for _, node := range c.orderers {
+		tlsCert, err := utils.ParseX509Certificate([]byte(node.node.Status.TlsCert))
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(tlsCert.Raw, consenter.ServerTLSCert.Raw) {
+			return node
+		}
+	}
+	return nil
+}
+
+func (c *rotateConsenterCmd) listChannels(node *rotateNode) ([]string, error) {
+	chResponse, err := osnadmin.ListAllChannels(node.osnURL, c.certPool, c.tlsClientCert)
+	if err != nil {
+		return nil, err
+	}
+	defer chResponse.Body.Close()
+	if chResponse.StatusCode != 200 {
+		return nil, errors.Errorf("error listing channels, got status code=%d", chResponse.StatusCode)
+	}
+	channelList := &osnadmin.ChannelList{}
+	err = json.NewDecoder(chResponse.Body).Decode(channelList)
+	if err != nil {
+		return nil, err
+	}
+	var channels []string
+	for _, channel := range channelList.Channels {
+		channels = append(channels, channel.Name)
+	}
+	return channels, nil
+}
+
+func (c *rotateConsenterCmd) channelInfo(node *rotateNode, channelID string) (*osnadmin.ChannelInfo, error) {
+	chResponse, err := osnadmin.ListSingleChannel(node.osnURL, channelID, c.certPool, c.tlsClientCert)
+	if err != nil {
+		return nil, err
+	}
+	defer chResponse.Body.Close()
+	if chResponse.StatusCode != 200 {
+		return nil, errors.Errorf("error getting channel %s, got status code=%d", channelID, chResponse.StatusCode)
+	}
+	chInfo := &osnadmin.ChannelInfo{}
+	err = json.NewDecoder(chResponse.Body).Decode(chInfo)
+	if err != nil {
+		return nil, err
+	}
+	return chInfo, nil
+}
+
+func (c *rotateConsenterCmd) waitFor(description string, condition func() (bool, error)) error {
+	deadline := time.Now().Add(c.timeout)
+	for {
+		done, err := condition()
+		if err == nil && done {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return errors.Wrapf(err, "timed out waiting for %s", description)
+			}
+			return errors.Errorf("timed out waiting for %s", description)
+		}
+		log.Infof("Waiting for %s", description)
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func newRotateConsenterCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &rotateConsenterCmd{}
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Replace an orderer node by another one in all the channels it's a consenter of",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.identity, "identity", "", "", "Admin identity for the channel participation API of the orderers")
+	persistentFlags.StringVarP(&c.oldOrderer, "old-orderer", "", "", "Orderer node to replace, e.g. ord-node1.default")
+	persistentFlags.StringVarP(&c.newOrderer, "new-orderer", "", "", "Orderer node replacing the old one, e.g. ord-node4.default")
+	persistentFlags.StringSliceVarP(&c.channels, "channels", "", []string{}, "Channels to rotate, all the channels of the old orderer if empty")
+	persistentFlags.StringVarP(&c.stateFile, "state-file", "", "", "File to store the progress of the rotation")
+	persistentFlags.BoolVarP(&c.resume, "resume", "", false, "Resume an interrupted rotation from the state file")
+	persistentFlags.DurationVarP(&c.timeout, "timeout", "", 10*time.Minute, "Maximum time to wait for the new orderer to catch up and become active")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	cmd.MarkPersistentFlagRequired("identity")
+	cmd.MarkPersistentFlagRequired("old-orderer")
+	cmd.MarkPersistentFlagRequired("new-orderer")
+	return cmd
+}
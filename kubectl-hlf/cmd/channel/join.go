@@ -1,10 +1,16 @@
 package channel
 
 import (
+	"fmt"
+	"os"
+	"sync"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"io"
@@ -15,12 +21,30 @@ type joinChannelCmd struct {
 	peer        string
 	channelName string
 	userName    string
+	mspID       string
+	allPeers    bool
+	concurrency int
 }
 
 func (c *joinChannelCmd) validate() error {
+	if c.allPeers {
+		if c.mspID == "" {
+			return errors.Errorf("--mspid is required with --all-peers")
+		}
+		if c.concurrency < 1 {
+			return errors.Errorf("--concurrency must be greater than 0")
+		}
+		return nil
+	}
+	if c.peer == "" {
+		return errors.Errorf("--peer is required")
+	}
 	return nil
 }
 func (c *joinChannelCmd) run() error {
+	if c.allPeers {
+		return c.joinAllPeers()
+	}
 	oclient, err := helpers.GetKubeOperatorClient()
 	if err != nil {
 		return err
@@ -55,6 +79,112 @@ func (c *joinChannelCmd) run() error {
 	log.Infof("Channel joined")
 	return nil
 }
+
+type joinResult struct {
+	peer   string
+	status string
+	err    error
+}
+
+// joinAllPeers joins every peer of the organization that is not yet part of the channel
+func (c *joinChannelCmd) joinAllPeers() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	_, peers, err := helpers.GetClusterPeers(clientSet, oclient, "")
+	if err != nil {
+		return err
+	}
+	var peerNames []string
+	for _, peer := range peers {
+		if peer.MSPID == c.mspID {
+			peerNames = append(peerNames, peer.Name)
+		}
+	}
+	if len(peerNames) == 0 {
+		return errors.Errorf("no peers found for MSP ID %s", c.mspID)
+	}
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	results := make([]joinResult, len(peerNames))
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, peerName := range peerNames {
+		wg.Add(1)
+		go func(i int, peerName string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = c.joinPeer(resClient, peerName)
+		}(i, peerName)
+	}
+	wg.Wait()
+
+	data := [][]string{}
+	failed := 0
+	for _, result := range results {
+		errMsg := ""
+		if result.err != nil {
+			failed++
+			errMsg = result.err.Error()
+		}
+		data = append(data, []string{result.peer, result.status, errMsg})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Peer", "Result", "Error"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	if failed > 0 {
+		return errors.Errorf("%d of %d peers failed to join the channel %s", failed, len(results), c.channelName)
+	}
+	return nil
+}
+
+func (c *joinChannelCmd) joinPeer(resClient *resmgmt.Client, peerName string) joinResult {
+	channelsResponse, err := resClient.QueryChannels(resmgmt.WithTargetEndpoints(peerName))
+	if err != nil {
+		return joinResult{peer: peerName, status: "Failed", err: fmt.Errorf("failed to query channels: %v", err)}
+	}
+	for _, channel := range channelsResponse.Channels {
+		if channel.ChannelId == c.channelName {
+			return joinResult{peer: peerName, status: "Skipped (already joined)"}
+		}
+	}
+	err = resClient.JoinChannel(c.channelName, resmgmt.WithTargetEndpoints(peerName))
+	if err != nil {
+		return joinResult{peer: peerName, status: "Failed", err: err}
+	}
+	log.Infof("Peer %s joined channel %s", peerName, c.channelName)
+	return joinResult{peer: peerName, status: "Joined"}
+}
+
 func newJoinChannelCMD(io.Writer, io.Writer) *cobra.Command {
 	c := &joinChannelCmd{}
 	cmd := &cobra.Command{
@@ -71,9 +201,11 @@ func newJoinChannelCMD(io.Writer, io.Writer) *cobra.Command {
 	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
 	persistentFlags.StringVarP(&c.channelName, "name", "", "", "Channel name")
 	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization whose peers are joined with --all-peers")
+	persistentFlags.BoolVarP(&c.allPeers, "all-peers", "", false, "Join all the peers of the organization")
+	persistentFlags.IntVarP(&c.concurrency, "concurrency", "", 4, "Maximum number of peers joined at the same time")
 	cmd.MarkPersistentFlagRequired("name")
 	cmd.MarkPersistentFlagRequired("user")
-	cmd.MarkPersistentFlagRequired("peer")
 	cmd.MarkPersistentFlagRequired("config")
 	return cmd
 }
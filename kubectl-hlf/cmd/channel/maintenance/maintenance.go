@@ -0,0 +1,20 @@
+package maintenance
+
+import (
+	"io"
+
+	"github.com/hyperledger/fabric-config/configtx/orderer"
+	"github.com/spf13/cobra"
+)
+
+func NewMaintenanceCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	maintenanceCmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Put a channel in maintenance mode or back to normal operation",
+	}
+	maintenanceCmd.AddCommand(
+		newSetMaintenanceCMD("on", "Put the channel in maintenance mode, only orderer admins can transact", orderer.ConsensusStateMaintenance),
+		newSetMaintenanceCMD("off", "Put the channel back to normal operation", orderer.ConsensusStateNormal),
+	)
+	return maintenanceCmd
+}
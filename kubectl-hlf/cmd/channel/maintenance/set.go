@@ -0,0 +1,148 @@
+package maintenance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-config/configtx/orderer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type setMaintenanceCmd struct {
+	configPath  string
+	channelName string
+	userName    string
+	mspID       string
+	output      string
+	state       orderer.ConsensusState
+}
+
+func (c *setMaintenanceCmd) validate() error {
+	return nil
+}
+
+// checkMainChannel refuses to change the state of a channel managed by a FabricMainChannel with a
+// different state in its spec, the operator would put the channel back on the next reconcile
+func (c *setMaintenanceCmd) checkMainChannel() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	mainChannels, err := oclient.HlfV1alpha1().FabricMainChannels().List(context.Background(), v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, mainChannel := range mainChannels.Items {
+		if mainChannel.Spec.Name != c.channelName {
+			continue
+		}
+		specState := string(orderer.ConsensusStateNormal)
+		if mainChannel.Spec.ChannelConfig != nil && mainChannel.Spec.ChannelConfig.Orderer != nil && mainChannel.Spec.ChannelConfig.Orderer.State != "" {
+			specState = string(mainChannel.Spec.ChannelConfig.Orderer.State)
+		}
+		if specState != string(c.state) {
+			return errors.Errorf(
+				"channel %s is managed by the FabricMainChannel %s with state %s, set spec.channelConfig.orderer.state to %s in it instead",
+				c.channelName,
+				mainChannel.Name,
+				specState,
+				c.state,
+			)
+		}
+	}
+	return nil
+}
+
+func (c *setMaintenanceCmd) run() error {
+	err := c.checkMainChannel()
+	if err != nil {
+		return err
+	}
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	cfg, err := helpers.GetCurrentConfigFromPeer(resClient, c.channelName)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfg)
+	ordConfig, err := cftxGen.Orderer().Configuration()
+	if err != nil {
+		return err
+	}
+	if ordConfig.State == c.state {
+		log.Infof("Channel %s is already in %s", c.channelName, c.state)
+		return nil
+	}
+	fmt.Printf("State: %s -> %s\n", ordConfig.State, c.state)
+	err = cftxGen.Orderer().SetConsensusState(c.state)
+	if err != nil {
+		return err
+	}
+	channelConfigBytes, err := helpers.ComputeConfigUpdateEnvelope(&cftxGen, c.channelName)
+	if err != nil {
+		return err
+	}
+	if c.output != "" {
+		err = ioutil.WriteFile(c.output, channelConfigBytes, 0644)
+		if err != nil {
+			return err
+		}
+		log.Infof("output file: %s", c.output)
+		return nil
+	}
+	chResponse, err := resClient.SaveChannel(resmgmt.SaveChannelRequest{
+		ChannelID:     c.channelName,
+		ChannelConfig: bytes.NewReader(channelConfigBytes),
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("channel %s is now in %s: %s", c.channelName, c.state, chResponse.TransactionID)
+	return nil
+}
+
+func newSetMaintenanceCMD(use string, short string, state orderer.ConsensusState) *cobra.Command {
+	c := &setMaintenanceCmd{state: state}
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name of an orderer admin")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the orderer organization")
+	persistentFlags.StringVarP(&c.output, "output", "o", "", "Write the config update to this file instead of submitting it")
+	cmd.MarkPersistentFlagRequired("channel")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	return cmd
+}
@@ -0,0 +1,17 @@
+package ordererparams
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func NewOrdererParamsCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	ordererParamsCmd := &cobra.Command{
+		Use: "orderer-params",
+	}
+	ordererParamsCmd.AddCommand(
+		newSetOrdererParamsCMD(stdOut, stdErr),
+	)
+	return ordererParamsCmd
+}
@@ -0,0 +1,306 @@
+package ordererparams
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-config/configtx/orderer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// maxAbsoluteMaxBytes is the largest block size the orderers accept with the default gRPC message limit
+const maxAbsoluteMaxBytes = 99 * 1024 * 1024
+
+type setOrdererParamsCmd struct {
+	configPath           string
+	channelName          string
+	userName             string
+	mspID                string
+	batchTimeout         string
+	maxMessageCount      uint32
+	absoluteMaxBytes     uint32
+	preferredMaxBytes    uint32
+	tickInterval         string
+	electionTick         uint32
+	heartbeatTick        uint32
+	snapshotIntervalSize uint32
+	output               string
+	signature            string
+	changed              func(name string) bool
+}
+
+func (c *setOrdererParamsCmd) validate() error {
+	if c.signature != "" && c.output == "" {
+		return errors.Errorf("--signature requires --output, the signature must be distributed with the config update")
+	}
+	return nil
+}
+
+// applyParams merges the flags that were set with the current values and validates the result
+func (c *setOrdererParamsCmd) applyParams(current configtx.Orderer) (configtx.Orderer, error) {
+	target := current
+	if c.changed("batch-timeout") {
+		batchTimeout, err := time.ParseDuration(c.batchTimeout)
+		if err != nil {
+			return target, errors.Wrapf(err, "invalid batch timeout %s", c.batchTimeout)
+		}
+		target.BatchTimeout = batchTimeout
+	}
+	if c.changed("max-message-count") {
+		target.BatchSize.MaxMessageCount = c.maxMessageCount
+	}
+	if c.changed("absolute-max-bytes") {
+		target.BatchSize.AbsoluteMaxBytes = c.absoluteMaxBytes
+	}
+	if c.changed("preferred-max-bytes") {
+		target.BatchSize.PreferredMaxBytes = c.preferredMaxBytes
+	}
+	if c.changed("tick-interval") {
+		target.EtcdRaft.Options.TickInterval = c.tickInterval
+	}
+	if c.changed("election-tick") {
+		target.EtcdRaft.Options.ElectionTick = c.electionTick
+	}
+	if c.changed("heartbeat-tick") {
+		target.EtcdRaft.Options.HeartbeatTick = c.heartbeatTick
+	}
+	if c.changed("snapshot-interval-size") {
+		target.EtcdRaft.Options.SnapshotIntervalSize = c.snapshotIntervalSize
+	}
+	if target.BatchTimeout <= 0 {
+		return target, errors.Errorf("batch timeout must be greater than 0")
+	}
+	if target.BatchSize.MaxMessageCount == 0 {
+		return target, errors.Errorf("max message count must be greater than 0")
+	}
+	if target.BatchSize.AbsoluteMaxBytes == 0 {
+		return target, errors.Errorf("absolute max bytes must be greater than 0")
+	}
+	if target.BatchSize.AbsoluteMaxBytes > maxAbsoluteMaxBytes {
+		return target, errors.Errorf("absolute max bytes must be at most %d", maxAbsoluteMaxBytes)
+	}
+	if target.BatchSize.PreferredMaxBytes == 0 {
+		return target, errors.Errorf("preferred max bytes must be greater than 0")
+	}
+	if target.BatchSize.PreferredMaxBytes > target.BatchSize.AbsoluteMaxBytes {
+		return target, errors.Errorf(
+			"preferred max bytes (%d) must be lower or equal than absolute max bytes (%d)",
+			target.BatchSize.PreferredMaxBytes,
+			target.BatchSize.AbsoluteMaxBytes,
+		)
+	}
+	tickInterval, err := time.ParseDuration(target.EtcdRaft.Options.TickInterval)
+	if err != nil {
+		return target, errors.Wrapf(err, "invalid tick interval %s", target.EtcdRaft.Options.TickInterval)
+	}
+	if tickInterval <= 0 {
+		return target, errors.Errorf("tick interval must be greater than 0")
+	}
+	if target.EtcdRaft.Options.HeartbeatTick == 0 {
+		return target, errors.Errorf("heartbeat tick must be greater than 0")
+	}
+	if target.EtcdRaft.Options.ElectionTick <= target.EtcdRaft.Options.HeartbeatTick {
+		return target, errors.Errorf(
+			"election tick (%d) must be greater than heartbeat tick (%d)",
+			target.EtcdRaft.Options.ElectionTick,
+			target.EtcdRaft.Options.HeartbeatTick,
+		)
+	}
+	if target.EtcdRaft.Options.SnapshotIntervalSize == 0 {
+		return target, errors.Errorf("snapshot interval size must be greater than 0")
+	}
+	return target, nil
+}
+
+func (c *setOrdererParamsCmd) setParams(cftxGen *configtx.ConfigTx, current configtx.Orderer, target configtx.Orderer) (int, error) {
+	ord := cftxGen.Orderer()
+	changes := 0
+	printChange := func(name string, from interface{}, to interface{}) {
+		fmt.Printf("%s: %v -> %v\n", name, from, to)
+		changes++
+	}
+	var err error
+	if current.BatchTimeout != target.BatchTimeout {
+		printChange("BatchTimeout", current.BatchTimeout, target.BatchTimeout)
+		if err = ord.SetBatchTimeout(target.BatchTimeout); err != nil {
+			return changes, err
+		}
+	}
+	if current.BatchSize.MaxMessageCount != target.BatchSize.MaxMessageCount {
+		printChange("BatchSize.MaxMessageCount", current.BatchSize.MaxMessageCount, target.BatchSize.MaxMessageCount)
+		if err = ord.BatchSize().SetMaxMessageCount(target.BatchSize.MaxMessageCount); err != nil {
+			return changes, err
+		}
+	}
+	if current.BatchSize.AbsoluteMaxBytes != target.BatchSize.AbsoluteMaxBytes {
+		printChange("BatchSize.AbsoluteMaxBytes", current.BatchSize.AbsoluteMaxBytes, target.BatchSize.AbsoluteMaxBytes)
+		if err = ord.BatchSize().SetAbsoluteMaxBytes(target.BatchSize.AbsoluteMaxBytes); err != nil {
+			return changes, err
+		}
+	}
+	if current.BatchSize.PreferredMaxBytes != target.BatchSize.PreferredMaxBytes {
+		printChange("BatchSize.PreferredMaxBytes", current.BatchSize.PreferredMaxBytes, target.BatchSize.PreferredMaxBytes)
+		if err = ord.BatchSize().SetPreferredMaxBytes(target.BatchSize.PreferredMaxBytes); err != nil {
+			return changes, err
+		}
+	}
+	currentOptions := current.EtcdRaft.Options
+	targetOptions := target.EtcdRaft.Options
+	if currentOptions.TickInterval != targetOptions.TickInterval {
+		printChange("EtcdRaft.TickInterval", currentOptions.TickInterval, targetOptions.TickInterval)
+		if err = ord.EtcdRaftOptions().SetTickInterval(targetOptions.TickInterval); err != nil {
+			return changes, err
+		}
+	}
+	if currentOptions.ElectionTick != targetOptions.ElectionTick {
+		printChange("EtcdRaft.ElectionTick", currentOptions.ElectionTick, targetOptions.ElectionTick)
+		if err = ord.EtcdRaftOptions().SetElectionInterval(targetOptions.ElectionTick); err != nil {
+			return changes, err
+		}
+	}
+	if currentOptions.HeartbeatTick != targetOptions.HeartbeatTick {
+		printChange("EtcdRaft.HeartbeatTick", currentOptions.HeartbeatTick, targetOptions.HeartbeatTick)
+		if err = ord.EtcdRaftOptions().SetHeartbeatTick(targetOptions.HeartbeatTick); err != nil {
+			return changes, err
+		}
+	}
+	if currentOptions.SnapshotIntervalSize != targetOptions.SnapshotIntervalSize {
+		printChange("EtcdRaft.SnapshotIntervalSize", currentOptions.SnapshotIntervalSize, targetOptions.SnapshotIntervalSize)
+		if err = ord.EtcdRaftOptions().SetSnapshotIntervalSize(targetOptions.SnapshotIntervalSize); err != nil {
+			return changes, err
+		}
+	}
+	return changes, nil
+}
+
+func (c *setOrdererParamsCmd) run() error {
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	clientContext := sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	)
+	resClient, err := resmgmt.New(clientContext)
+	if err != nil {
+		return err
+	}
+	cfg, err := helpers.GetCurrentConfigFromPeer(resClient, c.channelName)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfg)
+	current, err := cftxGen.Orderer().Configuration()
+	if err != nil {
+		return err
+	}
+	if current.OrdererType != orderer.ConsensusTypeEtcdRaft {
+		return errors.Errorf("channel %s uses the %s consensus type, only etcdraft is supported", c.channelName, current.OrdererType)
+	}
+	err = helpers.CheckOrdererNotInMaintenance(&cftxGen, c.channelName)
+	if err != nil {
+		return err
+	}
+	target, err := c.applyParams(current)
+	if err != nil {
+		return err
+	}
+	changes, err := c.setParams(&cftxGen, current, target)
+	if err != nil {
+		return err
+	}
+	if changes == 0 {
+		log.Infof("Orderer parameters are up to date")
+		return nil
+	}
+	channelConfigBytes, err := helpers.ComputeConfigUpdateEnvelope(&cftxGen, c.channelName)
+	if err != nil {
+		return err
+	}
+	if c.output != "" {
+		err = ioutil.WriteFile(c.output, channelConfigBytes, 0644)
+		if err != nil {
+			return err
+		}
+		log.Infof("output file: %s", c.output)
+		if c.signature == "" {
+			return nil
+		}
+		signingIdentity, err := clientContext()
+		if err != nil {
+			return err
+		}
+		signature, err := resClient.CreateConfigSignatureFromReader(signingIdentity, bytes.NewReader(channelConfigBytes))
+		if err != nil {
+			return err
+		}
+		signatureBytes, err := proto.Marshal(signature)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(c.signature, signatureBytes, 0644)
+		if err != nil {
+			return err
+		}
+		log.Infof("signature file: %s", c.signature)
+		return nil
+	}
+	chResponse, err := resClient.SaveChannel(resmgmt.SaveChannelRequest{
+		ChannelID:     c.channelName,
+		ChannelConfig: bytes.NewReader(channelConfigBytes),
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("orderer parameters updated: %s", chResponse.TransactionID)
+	return nil
+}
+
+func newSetOrdererParamsCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &setOrdererParamsCmd{}
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set the batch and raft parameters of an existing channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.changed = cmd.Flags().Changed
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization")
+	persistentFlags.StringVarP(&c.batchTimeout, "batch-timeout", "", "", "Batch timeout, e.g. 2s")
+	persistentFlags.Uint32VarP(&c.maxMessageCount, "max-message-count", "", 0, "Max message count")
+	persistentFlags.Uint32VarP(&c.absoluteMaxBytes, "absolute-max-bytes", "", 0, "Absolute max bytes")
+	persistentFlags.Uint32VarP(&c.preferredMaxBytes, "preferred-max-bytes", "", 0, "Preferred max bytes")
+	persistentFlags.StringVarP(&c.tickInterval, "tick-interval", "", "", "Etcd raft tick interval, e.g. 500ms")
+	persistentFlags.Uint32VarP(&c.electionTick, "election-tick", "", 0, "Etcd raft election tick")
+	persistentFlags.Uint32VarP(&c.heartbeatTick, "heartbeat-tick", "", 0, "Etcd raft heartbeat tick")
+	persistentFlags.Uint32VarP(&c.snapshotIntervalSize, "snapshot-interval-size", "", 0, "Etcd raft snapshot interval size in bytes")
+	persistentFlags.StringVarP(&c.output, "output", "o", "", "Write the config update to this file instead of submitting it")
+	persistentFlags.StringVarP(&c.signature, "signature", "", "", "Also sign the config update written to --output with the user and write the signature to this file")
+	cmd.MarkPersistentFlagRequired("channel")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	return cmd
+}
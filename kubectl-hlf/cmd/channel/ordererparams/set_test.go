@@ -0,0 +1,100 @@
+package ordererparams
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-config/configtx/orderer"
+)
+
+var currentOrderer = configtx.Orderer{
+	OrdererType:  orderer.ConsensusTypeEtcdRaft,
+	BatchTimeout: 2 * time.Second,
+	BatchSize: orderer.BatchSize{
+		MaxMessageCount:   10,
+		AbsoluteMaxBytes:  10 * 1024 * 1024,
+		PreferredMaxBytes: 2 * 1024 * 1024,
+	},
+	EtcdRaft: orderer.EtcdRaft{
+		Options: orderer.EtcdRaftOptions{
+			TickInterval:         "500ms",
+			ElectionTick:         10,
+			HeartbeatTick:        1,
+			SnapshotIntervalSize: 16 * 1024 * 1024,
+		},
+	},
+}
+
+func changedFlags(flags ...string) func(name string) bool {
+	return func(name string) bool {
+		for _, flag := range flags {
+			if flag == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestApplyParamsMergesSetFlags(t *testing.T) {
+	c := &setOrdererParamsCmd{
+		batchTimeout:  "1s",
+		electionTick:  20,
+		heartbeatTick: 5,
+		changed:       changedFlags("batch-timeout", "election-tick"),
+	}
+	target, err := c.applyParams(currentOrderer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.BatchTimeout != time.Second || target.EtcdRaft.Options.ElectionTick != 20 {
+		t.Errorf("flags not applied, got %+v", target)
+	}
+	if target.BatchSize != currentOrderer.BatchSize || target.EtcdRaft.Options.HeartbeatTick != 1 {
+		t.Errorf("unset flags changed the current values, got %+v", target)
+	}
+}
+
+func TestApplyParamsRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     *setOrdererParamsCmd
+		wantErr string
+	}{
+		{
+			name:    "preferred max bytes over absolute max bytes",
+			cmd:     &setOrdererParamsCmd{absoluteMaxBytes: 1024, changed: changedFlags("absolute-max-bytes")},
+			wantErr: "preferred max bytes (2097152) must be lower or equal than absolute max bytes (1024)",
+		},
+		{
+			name:    "absolute max bytes over the size cap",
+			cmd:     &setOrdererParamsCmd{absoluteMaxBytes: maxAbsoluteMaxBytes + 1, changed: changedFlags("absolute-max-bytes")},
+			wantErr: "absolute max bytes must be at most",
+		},
+		{
+			name:    "election tick not greater than heartbeat tick",
+			cmd:     &setOrdererParamsCmd{heartbeatTick: 10, changed: changedFlags("heartbeat-tick")},
+			wantErr: "election tick (10) must be greater than heartbeat tick (10)",
+		},
+		{
+			name:    "max message count set to 0",
+			cmd:     &setOrdererParamsCmd{changed: changedFlags("max-message-count")},
+			wantErr: "max message count must be greater than 0",
+		},
+		{
+			name:    "invalid tick interval",
+			cmd:     &setOrdererParamsCmd{tickInterval: "fast", changed: changedFlags("tick-interval")},
+			wantErr: "invalid tick interval fast",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cmd.applyParams(currentOrderer)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("applyParams() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
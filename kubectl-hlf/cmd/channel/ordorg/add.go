@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-config/configtx"
 	"github.com/hyperledger/fabric-protos-go/common"
 	cb "github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
@@ -54,6 +55,10 @@ func (c *addOrgCmd) run(out io.Writer) error {
 	if err != nil {
 		return err
 	}
+	cftxGen := configtx.New(channelConfig)
+	if err := helpers.CheckOrdererNotInMaintenance(&cftxGen, channelID); err != nil {
+		return err
+	}
 	modifiedConfig := &common.Config{}
 	modifiedConfigBytes, err := proto.Marshal(channelConfig)
 	if err != nil {
@@ -51,6 +51,9 @@ func (c *removeOrgCmd) run(out io.Writer) error {
 		return err
 	}
 	cftxGen := configtx.New(cfgBlock)
+	if err := helpers.CheckOrdererNotInMaintenance(&cftxGen, c.channelName); err != nil {
+		return err
+	}
 	cftxGen.Orderer().RemoveOrganization(c.mspID)
 	configUpdateBytes, err := cftxGen.ComputeMarshaledUpdate(c.channelName)
 	if err != nil {
@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"io"
+	"os"
+	"sort"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type getPolicyCmd struct {
+	configPath  string
+	channelName string
+	userName    string
+	mspID       string
+	level       string
+	org         string
+	name        string
+}
+
+func (c *getPolicyCmd) validate() error {
+	return validateLevel(c.level, c.org)
+}
+
+func (c *getPolicyCmd) run() error {
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	cfg, err := helpers.GetCurrentConfigFromPeer(resClient, c.channelName)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfg)
+	group, err := getPolicyGroup(&cftxGen, c.level, c.org)
+	if err != nil {
+		return err
+	}
+	policies, err := group.Policies()
+	if err != nil {
+		return err
+	}
+	if c.name != "" {
+		if _, ok := policies[c.name]; !ok {
+			return errors.Errorf("policy %s not found at the %s level of channel %s", c.name, c.level, c.channelName)
+		}
+	}
+	var names []string
+	for name := range policies {
+		if c.name == "" || c.name == name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var data [][]string
+	for _, name := range names {
+		policy := policies[name]
+		data = append(data, []string{name, policy.Type, policy.Rule, policy.ModPolicy})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Type", "Rule", "Mod Policy"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
+
+func newGetPolicyCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &getPolicyCmd{}
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Get the policies of a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization")
+	persistentFlags.StringVarP(&c.level, "level", "", levelApplication, "Level of the policies: channel, orderer, application or org")
+	persistentFlags.StringVarP(&c.org, "org", "", "", "MSP ID of the organization, required for the org level")
+	persistentFlags.StringVarP(&c.name, "name", "", "", "Only show this policy, e.g. Readers, Writers, Admins, Endorsement or LifecycleEndorsement")
+	cmd.MarkPersistentFlagRequired("channel")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	return cmd
+}
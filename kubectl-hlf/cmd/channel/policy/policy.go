@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"io"
+	"strings"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	levelChannel     = "channel"
+	levelOrderer     = "orderer"
+	levelApplication = "application"
+	levelOrg         = "org"
+)
+
+type policyGroup interface {
+	Policies() (map[string]configtx.Policy, error)
+	SetPolicy(policyName string, policy configtx.Policy) error
+}
+
+// getPolicyGroup returns the config group holding the policies of the level, for the org level the
+// organization is looked up in the application group first and then in the orderer group
+func getPolicyGroup(cftxGen *configtx.ConfigTx, level string, org string) (policyGroup, error) {
+	switch level {
+	case levelChannel:
+		return cftxGen.Channel(), nil
+	case levelOrderer:
+		return cftxGen.Orderer(), nil
+	case levelApplication:
+		return cftxGen.Application(), nil
+	case levelOrg:
+		if appOrg := cftxGen.Application().Organization(org); appOrg != nil {
+			return appOrg, nil
+		}
+		if ordOrg := cftxGen.Orderer().Organization(org); ordOrg != nil {
+			return ordOrg, nil
+		}
+		return nil, errors.Errorf("organization %s not found in the channel", org)
+	}
+	return nil, errors.Errorf("invalid level %s", level)
+}
+
+func validateLevel(level string, org string) error {
+	switch level {
+	case levelChannel, levelOrderer, levelApplication:
+		return nil
+	case levelOrg:
+		if org == "" {
+			return errors.Errorf("--org is required for the org level")
+		}
+		return nil
+	}
+	return errors.Errorf("invalid level %s, must be one of channel, orderer, application or org", level)
+}
+
+// parsePolicyType accepts both the configtx names and their lowercase variants
+func parsePolicyType(policyType string) (string, error) {
+	switch strings.ToLower(strings.ReplaceAll(policyType, "-", "")) {
+	case "implicitmeta":
+		return configtx.ImplicitMetaPolicyType, nil
+	case "signature":
+		return configtx.SignaturePolicyType, nil
+	}
+	return "", errors.Errorf("invalid policy type %s, must be ImplicitMeta or Signature", policyType)
+}
+
+func NewPolicyCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	policyCmd := &cobra.Command{
+		Use: "policy",
+	}
+	policyCmd.AddCommand(
+		newGetPolicyCMD(stdOut, stdErr),
+		newSetPolicyCMD(stdOut, stdErr),
+	)
+	return policyCmd
+}
@@ -0,0 +1,223 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type setPolicyCmd struct {
+	configPath  string
+	channelName string
+	userName    string
+	mspID       string
+	level       string
+	org         string
+	name        string
+	policyType  string
+	rule        string
+	modPolicy   string
+	output      string
+	mainChannel string
+}
+
+func (c *setPolicyCmd) validate() error {
+	err := validateLevel(c.level, c.org)
+	if err != nil {
+		return err
+	}
+	c.policyType, err = parsePolicyType(c.policyType)
+	if err != nil {
+		return err
+	}
+	if c.rule == "" {
+		return errors.Errorf("--rule is required")
+	}
+	if c.output != "" && c.mainChannel != "" {
+		return errors.Errorf("--output and --mainchannel can't be used together")
+	}
+	if c.mainChannel != "" && c.level == levelOrg {
+		return errors.Errorf("the FabricMainChannel doesn't support policies at the org level, use --output instead")
+	}
+	return nil
+}
+
+func (c *setPolicyCmd) run() error {
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	cfg, err := helpers.GetCurrentConfigFromPeer(resClient, c.channelName)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfg)
+	group, err := getPolicyGroup(&cftxGen, c.level, c.org)
+	if err != nil {
+		return err
+	}
+	if _, ok := group.(*configtx.OrdererOrg); ok || c.level == levelOrderer {
+		err = helpers.CheckOrdererNotInMaintenance(&cftxGen, c.channelName)
+		if err != nil {
+			return err
+		}
+	}
+	policies, err := group.Policies()
+	if err != nil {
+		return err
+	}
+	policy := configtx.Policy{
+		Type:      c.policyType,
+		Rule:      c.rule,
+		ModPolicy: c.modPolicy,
+	}
+	currentPolicy, exists := policies[c.name]
+	if policy.ModPolicy == "" {
+		policy.ModPolicy = currentPolicy.ModPolicy
+	}
+	if exists {
+		if currentPolicy == policy {
+			log.Infof("Policy %s is up to date", c.name)
+			return nil
+		}
+		fmt.Printf("- %s %s %s\n", c.name, currentPolicy.Type, currentPolicy.Rule)
+	}
+	fmt.Printf("+ %s %s %s\n", c.name, policy.Type, policy.Rule)
+	err = group.SetPolicy(c.name, policy)
+	if err != nil {
+		return errors.Wrapf(err, "invalid policy %s", c.name)
+	}
+	if c.mainChannel != "" {
+		updatedPolicies, err := group.Policies()
+		if err != nil {
+			return err
+		}
+		return c.updateMainChannel(updatedPolicies)
+	}
+	channelConfigBytes, err := helpers.ComputeConfigUpdateEnvelope(&cftxGen, c.channelName)
+	if err != nil {
+		return err
+	}
+	if c.output != "" {
+		err = ioutil.WriteFile(c.output, channelConfigBytes, 0644)
+		if err != nil {
+			return err
+		}
+		log.Infof("output file: %s", c.output)
+		return nil
+	}
+	chResponse, err := resClient.SaveChannel(resmgmt.SaveChannelRequest{
+		ChannelID:     c.channelName,
+		ChannelConfig: bytes.NewReader(channelConfigBytes),
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("policy %s updated: %s", c.name, chResponse.TransactionID)
+	return nil
+}
+
+// updateMainChannel sets all the policies of the level in the FabricMainChannel, since the operator
+// replaces the policies of the group with the ones in the spec
+func (c *setPolicyCmd) updateMainChannel(policies map[string]configtx.Policy) error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	fabricMainChannel, err := oclient.HlfV1alpha1().FabricMainChannels().Get(ctx, c.mainChannel, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if fabricMainChannel.Spec.Name != c.channelName {
+		return errors.Errorf("main channel %s manages channel %s, not %s", c.mainChannel, fabricMainChannel.Spec.Name, c.channelName)
+	}
+	specPolicies := map[string]v1alpha1.FabricMainChannelPoliciesConfig{}
+	for name, policy := range policies {
+		specPolicies[name] = v1alpha1.FabricMainChannelPoliciesConfig{
+			Type:      policy.Type,
+			Rule:      policy.Rule,
+			ModPolicy: policy.ModPolicy,
+		}
+	}
+	if fabricMainChannel.Spec.ChannelConfig == nil {
+		fabricMainChannel.Spec.ChannelConfig = &v1alpha1.FabricMainChannelConfig{}
+	}
+	channelConfig := fabricMainChannel.Spec.ChannelConfig
+	switch c.level {
+	case levelChannel:
+		channelConfig.Policies = &specPolicies
+	case levelOrderer:
+		if channelConfig.Orderer == nil {
+			channelConfig.Orderer = &v1alpha1.FabricMainChannelOrdererConfig{}
+		}
+		channelConfig.Orderer.Policies = &specPolicies
+	case levelApplication:
+		if channelConfig.Application == nil {
+			channelConfig.Application = &v1alpha1.FabricMainChannelApplicationConfig{}
+		}
+		channelConfig.Application.Policies = &specPolicies
+	}
+	_, err = oclient.HlfV1alpha1().FabricMainChannels().Update(ctx, fabricMainChannel, v1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("MainChannel %s updated", fabricMainChannel.Name)
+	return nil
+}
+
+func newSetPolicyCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &setPolicyCmd{}
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set a policy of a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization")
+	persistentFlags.StringVarP(&c.level, "level", "", levelApplication, "Level of the policy: channel, orderer, application or org")
+	persistentFlags.StringVarP(&c.org, "org", "", "", "MSP ID of the organization, required for the org level")
+	persistentFlags.StringVarP(&c.name, "name", "", "", "Policy name, e.g. Readers, Writers, Admins, Endorsement or LifecycleEndorsement")
+	persistentFlags.StringVarP(&c.policyType, "type", "", configtx.ImplicitMetaPolicyType, "Policy type: ImplicitMeta or Signature")
+	persistentFlags.StringVarP(&c.rule, "rule", "", "", "Policy rule, e.g. \"MAJORITY Admins\" or \"OR('Org1MSP.admin','Org2MSP.admin')\"")
+	persistentFlags.StringVarP(&c.modPolicy, "mod-policy", "", "", "Policy required to modify this policy, defaults to the current one")
+	persistentFlags.StringVarP(&c.output, "output", "o", "", "Write the config update to this file instead of submitting it")
+	persistentFlags.StringVarP(&c.mainChannel, "mainchannel", "", "", "Apply the change through this FabricMainChannel instead of submitting it")
+	cmd.MarkPersistentFlagRequired("channel")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	cmd.MarkPersistentFlagRequired("name")
+	cmd.MarkPersistentFlagRequired("rule")
+	return cmd
+}
@@ -0,0 +1,236 @@
+package channel
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-config/configtx"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mspproto "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type removeOrgFromChannelCmd struct {
+	configPath  string
+	channelName string
+	userName    string
+	signMSPID   string
+	mspID       string
+	peer        string
+	output      string
+}
+
+func (c *removeOrgFromChannelCmd) validate() error {
+	return nil
+}
+
+// policyReferencesOrg checks if a signature policy rule, e.g. OR('Org1MSP.member'), names the organization
+func policyReferencesOrg(policy configtx.Policy, mspID string) bool {
+	return policy.Type == configtx.SignaturePolicyType && strings.Contains(policy.Rule, fmt.Sprintf("'%s.", mspID))
+}
+
+// signaturePolicyReferencesOrg checks if any of the principals of the signature policy belongs to the organization
+func signaturePolicyReferencesOrg(envelope *cb.SignaturePolicyEnvelope, mspID string) bool {
+	if envelope == nil {
+		return false
+	}
+	for _, principal := range envelope.Identities {
+		var principalMSPID string
+		switch principal.PrincipalClassification {
+		case mspproto.MSPPrincipal_ROLE:
+			role := &mspproto.MSPRole{}
+			if err := proto.Unmarshal(principal.Principal, role); err == nil {
+				principalMSPID = role.MspIdentifier
+			}
+		case mspproto.MSPPrincipal_ORGANIZATION_UNIT:
+			ou := &mspproto.OrganizationUnit{}
+			if err := proto.Unmarshal(principal.Principal, ou); err == nil {
+				principalMSPID = ou.MspIdentifier
+			}
+		case mspproto.MSPPrincipal_IDENTITY:
+			id := &mspproto.SerializedIdentity{}
+			if err := proto.Unmarshal(principal.Principal, id); err == nil {
+				principalMSPID = id.Mspid
+			}
+		}
+		if principalMSPID == mspID {
+			return true
+		}
+	}
+	return false
+}
+
+func policyRefReferencesOrg(policyRef string, mspID string) bool {
+	return strings.Contains(policyRef, fmt.Sprintf("/%s/", mspID))
+}
+
+// findChannelReferences returns the channel policies and ACLs that reference the organization
+func (c *removeOrgFromChannelCmd) findChannelReferences(cftxGen *configtx.ConfigTx) ([]string, error) {
+	var references []string
+	groups := map[string]interface {
+		Policies() (map[string]configtx.Policy, error)
+	}{
+		"Channel":             cftxGen.Channel(),
+		"Channel/Application": cftxGen.Application(),
+		"Channel/Orderer":     cftxGen.Orderer(),
+	}
+	appConfig, err := cftxGen.Application().Configuration()
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range appConfig.Organizations {
+		if org.Name != c.mspID {
+			groups[fmt.Sprintf("Channel/Application/%s", org.Name)] = cftxGen.Application().Organization(org.Name)
+		}
+	}
+	ordConfig, err := cftxGen.Orderer().Configuration()
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range ordConfig.Organizations {
+		groups[fmt.Sprintf("Channel/Orderer/%s", org.Name)] = cftxGen.Orderer().Organization(org.Name)
+	}
+	for path, group := range groups {
+		policies, err := group.Policies()
+		if err != nil {
+			return nil, err
+		}
+		for name, policy := range policies {
+			if policyReferencesOrg(policy, c.mspID) {
+				references = append(references, fmt.Sprintf("policy /%s/%s: %s", path, name, policy.Rule))
+			}
+		}
+	}
+	acls, err := cftxGen.Application().ACLs()
+	if err != nil {
+		return nil, err
+	}
+	for resource, policyRef := range acls {
+		if policyRefReferencesOrg(policyRef, c.mspID) {
+			references = append(references, fmt.Sprintf("ACL %s: %s", resource, policyRef))
+		}
+	}
+	return references, nil
+}
+
+// findChaincodeReferences returns the endorsement and collection policies of the committed chaincodes
+// that reference the organization
+func (c *removeOrgFromChannelCmd) findChaincodeReferences(resClient *resmgmt.Client) ([]string, error) {
+	var options []resmgmt.RequestOption
+	if c.peer != "" {
+		options = append(options, resmgmt.WithTargetEndpoints(c.peer))
+	}
+	chaincodes, err := resClient.LifecycleQueryCommittedCC(c.channelName, resmgmt.LifecycleQueryCommittedCCRequest{}, options...)
+	if err != nil {
+		return nil, err
+	}
+	var references []string
+	for _, chaincode := range chaincodes {
+		if signaturePolicyReferencesOrg(chaincode.SignaturePolicy, c.mspID) || policyRefReferencesOrg(chaincode.ChannelConfigPolicy, c.mspID) {
+			references = append(references, fmt.Sprintf("endorsement policy of chaincode %s", chaincode.Name))
+		}
+		for _, collection := range chaincode.CollectionConfig {
+			staticCollection := collection.GetStaticCollectionConfig()
+			if staticCollection == nil {
+				continue
+			}
+			if signaturePolicyReferencesOrg(staticCollection.GetMemberOrgsPolicy().GetSignaturePolicy(), c.mspID) {
+				references = append(references, fmt.Sprintf("member policy of collection %s of chaincode %s", staticCollection.Name, chaincode.Name))
+			}
+			endorsementPolicy := staticCollection.GetEndorsementPolicy()
+			if signaturePolicyReferencesOrg(endorsementPolicy.GetSignaturePolicy(), c.mspID) ||
+				policyRefReferencesOrg(endorsementPolicy.GetChannelConfigPolicyReference(), c.mspID) {
+				references = append(references, fmt.Sprintf("endorsement policy of collection %s of chaincode %s", staticCollection.Name, chaincode.Name))
+			}
+		}
+	}
+	return references, nil
+}
+
+func (c *removeOrgFromChannelCmd) run() error {
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.signMSPID),
+	))
+	if err != nil {
+		return err
+	}
+	cfg, err := helpers.GetCurrentConfigFromPeer(resClient, c.channelName)
+	if err != nil {
+		return err
+	}
+	cftxGen := configtx.New(cfg)
+	if cftxGen.Application().Organization(c.mspID) == nil {
+		return errors.Errorf("organization %s not found in the application group of channel %s", c.mspID, c.channelName)
+	}
+	references, err := c.findChannelReferences(&cftxGen)
+	if err != nil {
+		return err
+	}
+	chaincodeReferences, err := c.findChaincodeReferences(resClient)
+	if err != nil {
+		log.Warnf("Couldn't check the chaincode definitions of channel %s: %v", c.channelName, err)
+	}
+	references = append(references, chaincodeReferences...)
+	for _, reference := range references {
+		log.Warnf("%s is referenced in the %s", c.mspID, reference)
+	}
+	if len(references) > 0 {
+		log.Warnf("Update the %d policies referencing %s, they won't be satisfiable once the organization is removed", len(references), c.mspID)
+	}
+	cftxGen.Application().RemoveOrganization(c.mspID)
+	channelConfigBytes, err := helpers.ComputeConfigUpdateEnvelope(&cftxGen, c.channelName)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(c.output, channelConfigBytes, 0644)
+	if err != nil {
+		return err
+	}
+	log.Infof("output file: %s", c.output)
+	return nil
+}
+
+func newRemoveOrgFromChannelCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &removeOrgFromChannelCmd{}
+	cmd := &cobra.Command{
+		Use:   "removeorg",
+		Short: "Generate the config update to remove an application organization from a channel",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.signMSPID, "config-msp-id", "", "", "MSP ID of the organization of the user")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization to remove")
+	persistentFlags.StringVarP(&c.peer, "peer", "", "", "Peer used to query the chaincode definitions of the channel")
+	persistentFlags.StringVarP(&c.output, "output", "o", "", "Output file for the config update")
+	cmd.MarkPersistentFlagRequired("channel")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("config-msp-id")
+	cmd.MarkPersistentFlagRequired("mspid")
+	cmd.MarkPersistentFlagRequired("output")
+	return cmd
+}
@@ -0,0 +1,441 @@
+package externalchaincode
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/policydsl"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const deployPollInterval = 5 * time.Second
+
+type deployExternalChaincodeCmd struct {
+	syncExternalChaincodeCmd
+
+	configPath  string
+	userName    string
+	mspID       string
+	label       string
+	channelName string
+	policy      string
+	version     string
+	sequence    int64
+	timeout     time.Duration
+}
+
+func (c *deployExternalChaincodeCmd) validate() error {
+	if c.label == "" {
+		c.label = c.name
+	}
+	if err := c.validateSpec(); err != nil {
+		return err
+	}
+	if c.channelName == "" {
+		return fmt.Errorf("--channel is required")
+	}
+	if c.configPath == "" {
+		return fmt.Errorf("--config is required")
+	}
+	if c.userName == "" {
+		return fmt.Errorf("--user is required")
+	}
+	if c.mspID == "" {
+		return fmt.Errorf("--mspid is required")
+	}
+	return nil
+}
+
+// getRootCert returns the certificate of the TLS CA that issues the certificate of the chaincode server
+func (c *deployExternalChaincodeCmd) getRootCert(ctx context.Context) (string, error) {
+	if !c.tlsRequired {
+		return "", nil
+	}
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return "", err
+	}
+	fabricCA, err := oclient.HlfV1alpha1().FabricCAs(c.caNamespace).Get(ctx, c.caName, v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if fabricCA.Status.TLSCACert == "" {
+		return "", errors.Errorf("CA %s.%s has no TLS CA certificate yet", c.caName, c.caNamespace)
+	}
+	return fabricCA.Status.TLSCACert, nil
+}
+
+// getOrgPeers returns the names of the peers of the organization in the cluster
+func (c *deployExternalChaincodeCmd) getOrgPeers() ([]string, error) {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return nil, err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, peers, err := helpers.GetClusterPeers(clientSet, oclient, "")
+	if err != nil {
+		return nil, err
+	}
+	var peerNames []string
+	for _, peer := range peers {
+		if peer.Spec.MspID == c.mspID {
+			peerNames = append(peerNames, peer.Name)
+		}
+	}
+	if len(peerNames) == 0 {
+		return nil, errors.Errorf("no peers found for organization %s", c.mspID)
+	}
+	sort.Strings(peerNames)
+	return peerNames, nil
+}
+
+// installPackage installs the package on the peers that don't have it yet
+func (c *deployExternalChaincodeCmd) installPackage(resClient *resmgmt.Client, peers []string, pkg []byte) error {
+	for _, peerName := range peers {
+		installed, err := resClient.LifecycleQueryInstalledCC(resmgmt.WithTargetEndpoints(peerName))
+		if err != nil {
+			return errors.Wrapf(err, "failed to query the chaincodes installed on %s", peerName)
+		}
+		alreadyInstalled := false
+		for _, chaincode := range installed {
+			if chaincode.PackageID == c.packageID {
+				alreadyInstalled = true
+			}
+		}
+		if alreadyInstalled {
+			log.Infof("Package %s already installed on %s", c.packageID, peerName)
+			continue
+		}
+		_, err = resClient.LifecycleInstallCC(
+			resmgmt.LifecycleInstallCCRequest{
+				Label:   c.label,
+				Package: pkg,
+			},
+			resmgmt.WithTargetEndpoints(peerName),
+			resmgmt.WithTimeout(fab.ResMgmt, 20*time.Minute),
+			resmgmt.WithTimeout(fab.PeerResponse, 20*time.Minute),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to install the chaincode on %s", peerName)
+		}
+		log.Infof("Package %s installed on %s", c.packageID, peerName)
+	}
+	return nil
+}
+
+// getSequence returns the sequence to approve, and false if the committed definition already points
+// to the package with the same policy
+func (c *deployExternalChaincodeCmd) getSequence(resClient *resmgmt.Client, peerName string, sp *common.SignaturePolicyEnvelope) (int64, bool, error) {
+	// all the definitions are queried, querying a single one fails when it isn't committed yet
+	committedCCs, err := resClient.LifecycleQueryCommittedCC(
+		c.channelName,
+		resmgmt.LifecycleQueryCommittedCCRequest{},
+		resmgmt.WithTargetEndpoints(peerName),
+	)
+	if err != nil {
+		return 0, false, err
+	}
+	var committed *resmgmt.LifecycleChaincodeDefinition
+	for idx := range committedCCs {
+		if committedCCs[idx].Name == c.name {
+			committed = &committedCCs[idx]
+		}
+	}
+	if committed == nil {
+		return c.nextSequence(0), true, nil
+	}
+	approved, err := resClient.LifecycleQueryApprovedCC(
+		c.channelName,
+		resmgmt.LifecycleQueryApprovedCCRequest{Name: c.name, Sequence: committed.Sequence},
+		resmgmt.WithTargetEndpoints(peerName),
+	)
+	if err == nil && approved.PackageID == c.packageID && committed.Version == c.version && samePolicy(sp, committed.SignaturePolicy) {
+		return committed.Sequence, false, nil
+	}
+	return c.nextSequence(committed.Sequence), true, nil
+}
+
+// samePolicy compares the requested policy with the committed one, no policy means the default
+// channel policy, which is committed without a signature policy
+func samePolicy(sp *common.SignaturePolicyEnvelope, committed *common.SignaturePolicyEnvelope) bool {
+	if sp == nil || committed == nil {
+		return sp == nil && committed == nil
+	}
+	return proto.Equal(sp, committed)
+}
+
+func (c *deployExternalChaincodeCmd) nextSequence(committed int64) int64 {
+	if c.sequence > 0 {
+		return c.sequence
+	}
+	return committed + 1
+}
+
+// waitForDeployment waits until the deployment of the chaincode has rolled out and all its pods are ready
+func (c *deployExternalChaincodeCmd) waitForDeployment(clientSet *kubernetes.Clientset) error {
+	ctx := context.Background()
+	deadline := time.Now().Add(c.timeout)
+	description := fmt.Sprintf("pods of chaincode %s.%s to be ready", c.name, c.namespace)
+	for {
+		ready, err := c.isDeploymentReady(ctx, clientSet)
+		if err == nil && ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return errors.Wrapf(err, "timed out waiting for %s", description)
+			}
+			return errors.Errorf("timed out waiting for %s", description)
+		}
+		log.Debugf("Waiting for %s", description)
+		time.Sleep(deployPollInterval)
+	}
+}
+
+// usesPackage checks if the operator already passed the package ID to the chaincode containers
+func usesPackage(podSpec corev1.PodSpec, packageID string) bool {
+	for _, container := range podSpec.Containers {
+		for _, env := range container.Env {
+			if env.Value == packageID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *deployExternalChaincodeCmd) isDeploymentReady(ctx context.Context, clientSet *kubernetes.Clientset) (bool, error) {
+	deployment, err := clientSet.AppsV1().Deployments(c.namespace).Get(ctx, c.name, v1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if deployment.Status.ObservedGeneration < deployment.Generation || !usesPackage(deployment.Spec.Template.Spec, c.packageID) {
+		return false, nil
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if deployment.Status.UpdatedReplicas != replicas ||
+		deployment.Status.ReadyReplicas != replicas ||
+		deployment.Status.AvailableReplicas != replicas {
+		return false, nil
+	}
+	selector, err := v1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return false, err
+	}
+	pods, err := clientSet.CoreV1().Pods(c.namespace).List(ctx, v1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return false, err
+	}
+	readyPods := int32(0)
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			return false, nil
+		}
+		if helpers.IsPodReady(pod) {
+			readyPods++
+		}
+	}
+	return readyPods == replicas, nil
+}
+
+func (c *deployExternalChaincodeCmd) run() error {
+	ctx := context.Background()
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	rootCert, err := c.getRootCert(ctx)
+	if err != nil {
+		return err
+	}
+	address := fmt.Sprintf("%s.%s:%d", c.name, c.namespace, c.chaincodeServerPort)
+	pkg, packageID, err := newCCaaSPackage(c.label, address, c.tlsRequired, rootCert)
+	if err != nil {
+		return errors.Wrap(err, "failed to build the chaincode package")
+	}
+	c.packageID = packageID
+	log.Infof("Package ID %s", packageID)
+
+	peers, err := c.getOrgPeers()
+	if err != nil {
+		return err
+	}
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	err = c.installPackage(resClient, peers, pkg)
+	if err != nil {
+		return err
+	}
+
+	var sp *common.SignaturePolicyEnvelope
+	if c.policy != "" {
+		sp, err = policydsl.FromString(c.policy)
+		if err != nil {
+			return err
+		}
+	}
+	sequence, pending, err := c.getSequence(resClient, peers[0], sp)
+	if err != nil {
+		return errors.Wrapf(err, "failed to query chaincode %s on channel %s", c.name, c.channelName)
+	}
+	if pending {
+		err = c.approveAndCommit(resClient, peers[0], sequence, sp)
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Infof("Chaincode %s already committed with sequence %d", c.name, sequence)
+	}
+
+	// the chaincode switches to the new package only once the channel references it
+	err = c.syncExternalChaincodeCmd.run()
+	if err != nil {
+		return err
+	}
+	err = c.waitForDeployment(clientSet)
+	if err != nil {
+		return err
+	}
+	log.Infof("Chaincode %s deployed on channel %s with package %s and sequence %d", c.name, c.channelName, packageID, sequence)
+	return nil
+}
+
+func (c *deployExternalChaincodeCmd) approveAndCommit(resClient *resmgmt.Client, peerName string, sequence int64, sp *common.SignaturePolicyEnvelope) error {
+	txID, err := resClient.LifecycleApproveCC(
+		c.channelName,
+		resmgmt.LifecycleApproveCCRequest{
+			Name:              c.name,
+			Version:           c.version,
+			PackageID:         c.packageID,
+			Sequence:          sequence,
+			EndorsementPlugin: "escc",
+			ValidationPlugin:  "vscc",
+			SignaturePolicy:   sp,
+		},
+		resmgmt.WithTargetEndpoints(peerName),
+		resmgmt.WithTimeout(fab.ResMgmt, 20*time.Minute),
+		resmgmt.WithTimeout(fab.PeerResponse, 20*time.Minute),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to approve chaincode %s with sequence %d", c.name, sequence)
+	}
+	log.Infof("Chaincode approved=%s", txID)
+
+	readiness, err := resClient.LifecycleCheckCCCommitReadiness(
+		c.channelName,
+		resmgmt.LifecycleCheckCCCommitReadinessRequest{
+			Name:              c.name,
+			Version:           c.version,
+			Sequence:          sequence,
+			EndorsementPlugin: "escc",
+			ValidationPlugin:  "vscc",
+			SignaturePolicy:   sp,
+		},
+		resmgmt.WithTargetEndpoints(peerName),
+	)
+	if err != nil {
+		return err
+	}
+	var pendingOrgs []string
+	for mspID, approved := range readiness.Approvals {
+		if !approved {
+			pendingOrgs = append(pendingOrgs, mspID)
+		}
+	}
+	if len(pendingOrgs) > 0 {
+		sort.Strings(pendingOrgs)
+		return errors.Errorf(
+			"chaincode %s sequence %d is pending approval from %s, deploy it from those organizations to commit it and run deploy again to switch the chaincode to the new package",
+			c.name,
+			sequence,
+			strings.Join(pendingOrgs, ", "),
+		)
+	}
+	txID, err = resClient.LifecycleCommitCC(
+		c.channelName,
+		resmgmt.LifecycleCommitCCRequest{
+			Name:              c.name,
+			Version:           c.version,
+			Sequence:          sequence,
+			EndorsementPlugin: "escc",
+			ValidationPlugin:  "vscc",
+			SignaturePolicy:   sp,
+		},
+		resmgmt.WithTimeout(fab.ResMgmt, 20*time.Minute),
+		resmgmt.WithTimeout(fab.PeerResponse, 20*time.Minute),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit chaincode %s with sequence %d", c.name, sequence)
+	}
+	log.Infof("Chaincode committed=%s", txID)
+	return nil
+}
+
+func newExternalChaincodeDeployCmd() *cobra.Command {
+	c := &deployExternalChaincodeCmd{}
+	cmd := &cobra.Command{
+		Use:   "deploy",
+		Short: "Package, install, approve and commit a chaincode as a service and wait for it to be ready",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.name, "name", "", "Name of the external chaincode")
+	f.StringVar(&c.namespace, "namespace", "", "Namespace of the external chaincode")
+	f.StringVar(&c.image, "image", "", "Image of the external chaincode")
+	f.StringVar(&c.label, "label", "", "Label of the chaincode package, defaults to the name")
+	f.StringVar(&c.channelName, "channel", "", "Channel to commit the chaincode")
+	f.StringVar(&c.policy, "policy", "", "Endorsement policy of the chaincode")
+	f.StringVar(&c.version, "version", "1.0", "Version of the chaincode")
+	f.Int64Var(&c.sequence, "sequence", 0, "Sequence number, by default the next one of the committed chaincode")
+	f.StringVar(&c.configPath, "config", "", "Configuration file for the SDK")
+	f.StringVar(&c.userName, "user", "", "User name for the transaction")
+	f.StringVar(&c.mspID, "mspid", "", "MSP ID of the organization whose peers install the chaincode")
+	f.StringVar(&c.caName, "ca-name", "", "CA name to enroll this user")
+	f.StringVar(&c.caNamespace, "ca-namespace", "", "Namespace of the CA")
+	f.StringVar(&c.enrollId, "enroll-id", "", "Enroll ID of the CA")
+	f.StringVar(&c.enrollSecret, "enroll-secret", "", "Enroll secret of the CA")
+	f.BoolVar(&c.tlsRequired, "tls-required", false, "Require TLS for chaincode")
+	f.IntVarP(&c.replicas, "replicas", "", 1, "Number of replicas of the chaincode")
+	f.StringArrayVarP(&c.ImagePullSecret, "image-pull-secret", "s", []string{}, "Image Pull Secret for the Chaincode Image")
+	f.StringArrayVarP(&c.Env, "env", "", []string{}, "Environment variable for the Chaincode (key=value)")
+	f.IntVar(&c.chaincodeServerPort, "port", 7052, "Chaincode Server Port")
+	f.DurationVar(&c.timeout, "timeout", 5*time.Minute, "Time to wait for the chaincode pods to be ready")
+	return cmd
+}
@@ -15,6 +15,7 @@ func NewExternalChaincodeCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command
 		newExternalChaincodeUpdateCmd(),
 		newExternalChaincodeDeleteCmd(),
 		newExternalChaincodeSyncCmd(),
+		newExternalChaincodeDeployCmd(),
 	)
 	return externalChaincodeCmd
 }
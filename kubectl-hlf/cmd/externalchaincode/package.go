@@ -0,0 +1,86 @@
+package externalchaincode
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager/lifecycle"
+)
+
+type ccaasConnection struct {
+	Address            string `json:"address"`
+	DialTimeout        string `json:"dial_timeout"`
+	TLSRequired        bool   `json:"tls_required"`
+	ClientAuthRequired bool   `json:"client_auth_required"`
+	RootCert           string `json:"root_cert,omitempty"`
+}
+
+type ccaasMetadata struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+// writeTarGz writes the files, in order, to a gzipped tar
+func writeTarGz(files []string, contents map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, name := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0644,
+			Size: int64(len(contents[name])),
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = tw.Write(contents[name])
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = gw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newCCaaSPackage builds the chaincode as a service package, the peers connect to the chaincode
+// at address, verifying it with rootCert when TLS is required
+func newCCaaSPackage(label string, address string, tlsRequired bool, rootCert string) ([]byte, string, error) {
+	connection := ccaasConnection{
+		Address:     address,
+		DialTimeout: "10s",
+		TLSRequired: tlsRequired,
+	}
+	if tlsRequired {
+		connection.RootCert = rootCert
+	}
+	connectionBytes, err := json.Marshal(connection)
+	if err != nil {
+		return nil, "", err
+	}
+	code, err := writeTarGz([]string{"connection.json"}, map[string][]byte{"connection.json": connectionBytes})
+	if err != nil {
+		return nil, "", err
+	}
+	metadataBytes, err := json.Marshal(ccaasMetadata{Type: "ccaas", Label: label})
+	if err != nil {
+		return nil, "", err
+	}
+	pkg, err := writeTarGz(
+		[]string{"metadata.json", "code.tar.gz"},
+		map[string][]byte{"metadata.json": metadataBytes, "code.tar.gz": code},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	return pkg, lifecycle.ComputePackageID(label, pkg), nil
+}
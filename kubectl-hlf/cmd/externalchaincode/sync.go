@@ -33,6 +33,14 @@ type syncExternalChaincodeCmd struct {
 }
 
 func (c *syncExternalChaincodeCmd) validate() error {
+	if c.packageID == "" {
+		return fmt.Errorf("--package-id is required")
+	}
+	return c.validateSpec()
+}
+
+// validateSpec validates the flags used to build the FabricChaincode spec
+func (c *syncExternalChaincodeCmd) validateSpec() error {
 	if c.name == "" {
 		return fmt.Errorf("--name is required")
 	}
@@ -42,9 +50,6 @@ func (c *syncExternalChaincodeCmd) validate() error {
 	if c.image == "" {
 		return fmt.Errorf("--image is required")
 	}
-	if c.packageID == "" {
-		return fmt.Errorf("--package-id is required")
-	}
 	if c.tlsRequired {
 		if c.caName == "" {
 			return fmt.Errorf("--ca-name is required")
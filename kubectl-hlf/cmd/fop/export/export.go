@@ -2,19 +2,23 @@ package export
 
 import (
 	"archive/zip"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
 )
 
 type exportFopCmd struct {
-	outFile string
+	outFile    string
+	namespaces []string
+	mspIDs     []string
+	hostURL    string
 }
 
 func (c exportFopCmd) validate() error {
@@ -25,14 +29,6 @@ func (c exportFopCmd) validate() error {
 }
 
 func (c exportFopCmd) run() error {
-	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
-	file, err := os.OpenFile(c.outFile, flags, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	zipw := zip.NewWriter(file)
-	defer zipw.Close()
 	oclient, err := helpers.GetKubeOperatorClient()
 	if err != nil {
 		return err
@@ -45,22 +41,51 @@ func (c exportFopCmd) run() error {
 	if err != nil {
 		return err
 	}
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	_, peers, err := helpers.GetClusterPeers(clientSet, oclient, "")
+	if err != nil {
+		return err
+	}
+	orderers, err := helpers.GetClusterOrdererNodes(clientSet, oclient, "")
+	if err != nil {
+		return err
 	}
-	client := &http.Client{Transport: tr}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	file, err := os.OpenFile(c.outFile, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	zipw := zip.NewWriter(file)
+	defer zipw.Close()
 	for _, fabricCA := range certAuths {
-		displayName := fmt.Sprintf("%s_%s", fabricCA.Object.Name, fabricCA.Object.Namespace)
+		if len(c.namespaces) > 0 && !utils.Contains(c.namespaces, fabricCA.Object.Namespace) {
+			continue
+		}
+		var mspIDs []string
+		for _, mspID := range getCAMSPIDs(fabricCA, peers, orderers) {
+			if len(c.mspIDs) == 0 || utils.Contains(c.mspIDs, mspID) {
+				mspIDs = append(mspIDs, mspID)
+			}
+		}
+		if len(mspIDs) == 0 {
+			log.Infof("Skipping CA %s.%s, no peers or orderers enrolled against it", fabricCA.Object.Name, fabricCA.Object.Namespace)
+			continue
+		}
+		client, err := helpers.NewCAHTTPClient(fabricCA.Status.TlsCert)
+		if err != nil {
+			return errors.Wrapf(err, "invalid CA %s.%s", fabricCA.Object.Name, fabricCA.Object.Namespace)
+		}
 		caURL := fmt.Sprintf("https://%s", fabricCA.PublicURL)
 		res, err := client.Get(fmt.Sprintf("%s/cainfo", caURL))
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "failed to get the info of CA %s.%s", fabricCA.Object.Name, fabricCA.Object.Namespace)
 		}
 		bodyBytes, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
 		if err != nil {
 			return err
 		}
-		baseFileName := fmt.Sprintf("%s", fmt.Sprintf("%s_%s.json", fabricCA.Object.Name, fabricCA.Object.Namespace))
+		baseFileName := fmt.Sprintf("%s_%s.json", fabricCA.Object.Name, fabricCA.Object.Namespace)
 		if err := appendFile(baseFileName, bodyBytes, zipw); err != nil {
 			return err
 		}
@@ -73,43 +98,28 @@ func (c exportFopCmd) run() error {
 		if err := appendFile(fileName, caBytes, zipw); err != nil {
 			return err
 		}
-
-		org := FabricOperationsOrg{
-			DisplayName:  displayName,
-			MspId:        fabricCA.Object.Name,
-			Type:         "msp",
-			Admins:       nil,
-			RootCerts:    []string{fabricCA.Status.CACert},
-			TlsRootCerts: []string{fabricCA.Status.TLSCACert},
-			FabricNodeOus: FabricNodeOus{
-				AdminOuIdentifier: FabricOrgOUIdentifier{
-					Certificate:                  fabricCA.Status.CACert,
-					OrganizationalUnitIdentifier: "admin",
-				},
-				ClientOuIdentifier: FabricOrgOUIdentifier{
-					Certificate:                  fabricCA.Status.CACert,
-					OrganizationalUnitIdentifier: "client",
-				},
-				Enable: true,
-				OrdererOuIdentifier: FabricOrgOUIdentifier{
-					Certificate:                  fabricCA.Status.CACert,
-					OrganizationalUnitIdentifier: "orderer",
-				},
-				PeerOuIdentifier: FabricOrgOUIdentifier{
-					Certificate:                  fabricCA.Status.CACert,
-					OrganizationalUnitIdentifier: "peer",
-				},
-			},
-			HostUrl: "http://console.hlf.kfs.es",
-			Name:    displayName,
-		}
-		orgBytes, err := json.MarshalIndent(org, "", "  ")
-		if err != nil {
-			return err
-		}
-		orgFileName := fmt.Sprintf("%s/%s/%s", "assets", "Organizations", fmt.Sprintf("%s_%s.json", fabricCA.Object.Name, fabricCA.Object.Namespace))
-		if err := appendFile(orgFileName, orgBytes, zipw); err != nil {
-			return err
+		for _, mspID := range mspIDs {
+			admins, err := getAdminCerts(clientSet, oclient, fabricCA, mspID)
+			if err != nil {
+				return err
+			}
+			org, err := mapFabricOperationsOrg(fabricCA, MapFabricOperationsOrg{
+				MSPID:   mspID,
+				HostURL: c.hostURL,
+				Admins:  admins,
+			})
+			if err != nil {
+				return err
+			}
+			orgBytes, err := json.MarshalIndent(org, "", "  ")
+			if err != nil {
+				return err
+			}
+			orgFileName := fmt.Sprintf("%s/%s/%s", "assets", "Organizations", fmt.Sprintf("%s.json", mspID))
+			if err := appendFile(orgFileName, orgBytes, zipw); err != nil {
+				return err
+			}
+			log.Infof("Exported organization %s from CA %s.%s with %d admins", mspID, fabricCA.Object.Name, fabricCA.Object.Namespace, len(admins))
 		}
 	}
 	return nil
@@ -130,11 +140,13 @@ func NewExportCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
 		newExportCACMD(),
 		newExportOrdererCMD(),
 		newExportPeerCMD(),
-		newExportCACMD(),
 		newExportOrgCMD(),
 	)
 	persistentFlags := cmd.PersistentFlags()
 	persistentFlags.StringVarP(&c.outFile, "out", "p", "", "ZIP Output file")
+	cmd.Flags().StringSliceVar(&c.namespaces, "namespaces", []string{}, "Namespaces of the CAs to export, all namespaces when empty")
+	cmd.Flags().StringSliceVar(&c.mspIDs, "mspids", []string{}, "MSP IDs of the organizations to export, all organizations when empty")
+	cmd.Flags().StringVar(&c.hostURL, "host-url", "", "URL of the Fabric Operations Console")
 	cmd.MarkPersistentFlagRequired("out")
 	return cmd
 }
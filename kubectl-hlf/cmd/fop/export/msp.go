@@ -0,0 +1,79 @@
+package export
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"sort"
+
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// getCAMSPIDs returns the MSP IDs of the peers and orderers enrolled against the CA
+func getCAMSPIDs(certAuth *helpers.ClusterCA, peers []*helpers.ClusterPeer, orderers []*helpers.ClusterOrdererNode) []string {
+	var mspIDs []string
+	for _, peer := range peers {
+		if helpers.CAHostMatches(certAuth, peer.Spec.Secret.Enrollment.Component.Cahost) && !utils.Contains(mspIDs, peer.Spec.MspID) {
+			mspIDs = append(mspIDs, peer.Spec.MspID)
+		}
+	}
+	for _, orderer := range orderers {
+		if helpers.CAHostMatches(certAuth, orderer.Spec.Secret.Enrollment.Component.Cahost) && !utils.Contains(mspIDs, orderer.Spec.MspID) {
+			mspIDs = append(mspIDs, orderer.Spec.MspID)
+		}
+	}
+	sort.Strings(mspIDs)
+	return mspIDs
+}
+
+// getAdminCerts returns the certificates, base64 encoded, of the FabricIdentities of the
+// organization enrolled against the CA with the admin OU
+func getAdminCerts(
+	clientSet *kubernetes.Clientset,
+	oclient *operatorv1.Clientset,
+	certAuth *helpers.ClusterCA,
+	mspID string,
+) ([]string, error) {
+	ctx := context.Background()
+	fabricIdentities, err := oclient.HlfV1alpha1().FabricIdentities("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	adminCerts := []string{}
+	for _, fabricIdentity := range fabricIdentities.Items {
+		if fabricIdentity.Spec.MSPID != mspID || !helpers.CAHostMatches(certAuth, fabricIdentity.Spec.Cahost) {
+			continue
+		}
+		secret, err := clientSet.CoreV1().Secrets(fabricIdentity.Namespace).Get(ctx, fabricIdentity.Name, v1.GetOptions{})
+		if err != nil {
+			log.Warnf("Couldn't get the secret of identity %s.%s: %v", fabricIdentity.Name, fabricIdentity.Namespace, err)
+			continue
+		}
+		user, err := helpers.GetFabricIdentityUser(secret)
+		if err != nil {
+			return nil, err
+		}
+		certPem := user.Cert.Pem
+		block, _ := pem.Decode([]byte(certPem))
+		if block == nil {
+			log.Warnf("Identity %s.%s has no certificate", fabricIdentity.Name, fabricIdentity.Namespace)
+			continue
+		}
+		crt, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the certificate of identity %s.%s", fabricIdentity.Name, fabricIdentity.Namespace)
+		}
+		if !utils.Contains(crt.Subject.OrganizationalUnit, "admin") {
+			continue
+		}
+		adminCerts = append(adminCerts, base64.StdEncoding.EncodeToString([]byte(certPem)))
+	}
+	return adminCerts, nil
+}
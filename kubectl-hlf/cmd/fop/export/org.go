@@ -31,9 +31,6 @@ func (c exportOrgCmd) validate() error {
 	if c.mspID == "" {
 		return fmt.Errorf("--msp-id is required")
 	}
-	if c.hostURL == "" {
-		return fmt.Errorf("--host-url is required")
-	}
 	return nil
 }
 func (c exportOrgCmd) run(args []string) error {
@@ -54,9 +51,14 @@ func (c exportOrgCmd) run(args []string) error {
 	if err != nil {
 		return err
 	}
+	admins, err := getAdminCerts(clientSet, oclient, clusterCA, c.mspID)
+	if err != nil {
+		return err
+	}
 	opOrg, err := mapFabricOperationsOrg(clusterCA, MapFabricOperationsOrg{
 		MSPID:   c.mspID,
 		HostURL: c.hostURL,
+		Admins:  admins,
 	})
 	if err != nil {
 		return err
@@ -92,6 +94,5 @@ func newExportOrgCMD() *cobra.Command {
 	cmd.MarkPersistentFlagRequired("namespace")
 	cmd.MarkPersistentFlagRequired("out")
 	cmd.MarkPersistentFlagRequired("msp-id")
-	cmd.MarkPersistentFlagRequired("host-url")
 	return cmd
 }
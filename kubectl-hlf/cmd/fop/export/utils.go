@@ -231,10 +231,11 @@ func mapFabricOperationsOrderer(clusterOrdererNode *helpers.ClusterOrdererNode,
 type MapFabricOperationsOrg struct {
 	MSPID   string
 	HostURL string
+	Admins  []string
 }
 
 func mapFabricOperationsOrg(clusterCA *helpers.ClusterCA, opts MapFabricOperationsOrg) (*FabricOperationsOrg, error) {
-	displayName := fmt.Sprintf("%s_%s", clusterCA.Object.Name, clusterCA.Object.Namespace)
+	displayName := opts.MSPID
 	if len(displayName) >= 30 {
 		displayName = displayName[0:29]
 	}
@@ -243,7 +244,7 @@ func mapFabricOperationsOrg(clusterCA *helpers.ClusterCA, opts MapFabricOperatio
 		MspId:        opts.MSPID,
 		Type:         "msp",
 		HostUrl:      opts.HostURL,
-		Admins:       []string{},
+		Admins:       opts.Admins,
 		RootCerts:    []string{base64.StdEncoding.EncodeToString([]byte(clusterCA.Object.Status.CACert))},
 		TlsRootCerts: []string{base64.StdEncoding.EncodeToString([]byte(clusterCA.Object.Status.TLSCACert))},
 		FabricNodeOus: FabricNodeOus{
@@ -2,6 +2,7 @@ package fop
 
 import (
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/fop/export"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/fop/importer"
 	"github.com/spf13/cobra"
 	"io"
 )
@@ -12,6 +13,7 @@ func NewFOPCMD(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
 	}
 	fopCmd.AddCommand(
 		export.NewExportCmd(stdOut, stdErr),
+		importer.NewImportCmd(stdOut, stdErr),
 	)
 	return fopCmd
 }
@@ -0,0 +1,450 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	importDesc = `
+'import' command reads a Fabric Operations Console export and generates the external peers and orderers
+and the MSP definitions of the organizations, ready to use with 'channel addorg'`
+	importExample = `  kubectl hlf fop import --zip console-export.zip --output-dir ./partner --anchor-peers partner-peer0
+  kubectl hlf channel addorg --name mychannel --peer org1-peer0.default --config org1.yaml --user admin --msp-id PartnerMSP --org-config ./partner/configtx.yaml
+  kubectl hlf fop import --zip console-export.zip --output-dir ./partner --networkconfig org1-nc --namespace default`
+	externalNodesFile = "external-nodes.yaml"
+	configtxFile      = "configtx.yaml"
+)
+
+type importFopCmd struct {
+	out           io.Writer
+	zipPath       string
+	outputDir     string
+	networkConfig string
+	namespace     string
+	anchorPeers   []string
+}
+
+type orgMSP struct {
+	MSPID        string
+	RootCerts    []string
+	TLSRootCerts []string
+	Admins       []string
+	NodeOUs      bool
+	AnchorPeers  []anchorPeer
+}
+
+type anchorPeer struct {
+	Host string `yaml:"Host"`
+	Port int    `yaml:"Port"`
+}
+
+type configtxPolicy struct {
+	Type string `yaml:"Type"`
+	Rule string `yaml:"Rule"`
+}
+
+type configtxOrganization struct {
+	Name        string                    `yaml:"Name"`
+	ID          string                    `yaml:"ID"`
+	MSPDir      string                    `yaml:"MSPDir"`
+	MSPType     string                    `yaml:"MSPType"`
+	Policies    map[string]configtxPolicy `yaml:"Policies"`
+	AnchorPeers []anchorPeer              `yaml:"AnchorPeers,omitempty"`
+}
+
+type configtx struct {
+	Organizations []configtxOrganization `yaml:"Organizations"`
+}
+
+type nodeOUIdentifier struct {
+	Certificate                  string `yaml:"Certificate"`
+	OrganizationalUnitIdentifier string `yaml:"OrganizationalUnitIdentifier"`
+}
+
+type nodeOUs struct {
+	Enable              bool             `yaml:"Enable"`
+	ClientOUIdentifier  nodeOUIdentifier `yaml:"ClientOUIdentifier"`
+	PeerOUIdentifier    nodeOUIdentifier `yaml:"PeerOUIdentifier"`
+	AdminOUIdentifier   nodeOUIdentifier `yaml:"AdminOUIdentifier"`
+	OrdererOUIdentifier nodeOUIdentifier `yaml:"OrdererOUIdentifier"`
+}
+
+type mspConfig struct {
+	NodeOUs nodeOUs `yaml:"NodeOUs"`
+}
+
+func (c *importFopCmd) validate() error {
+	if c.zipPath == "" {
+		return fmt.Errorf("--zip is required")
+	}
+	if c.outputDir == "" {
+		return fmt.Errorf("--output-dir is required")
+	}
+	if c.networkConfig != "" && c.namespace == "" {
+		return fmt.Errorf("--namespace is required with --networkconfig")
+	}
+	return nil
+}
+
+func decodeCerts(certs []string) []string {
+	var decoded []string
+	for _, cert := range certs {
+		if cert != "" {
+			decoded = append(decoded, decodeCert(cert))
+		}
+	}
+	return decoded
+}
+
+func getOrgMSP(orgs map[string]*orgMSP, mspID string) *orgMSP {
+	org, ok := orgs[mspID]
+	if !ok {
+		org = &orgMSP{MSPID: mspID}
+		orgs[mspID] = org
+	}
+	return org
+}
+
+// getExternalNodes maps the console peers and orderers, the TLS CA is taken from the root cert of
+// the node or from the TLS CA of its MSP
+func getExternalNodes(assets *consoleAssets) (*helpers.ExternalNodes, error) {
+	externalNodes := &helpers.ExternalNodes{}
+	for _, peer := range assets.Peers {
+		tlsCACert := decodeCert(peer.TlsCaRootCert)
+		if tlsCACert == "" && len(peer.Msp.TLSCA.RootCerts) > 0 {
+			tlsCACert = decodeCert(peer.Msp.TLSCA.RootCerts[0])
+		}
+		externalNodes.Peers = append(externalNodes.Peers, helpers.ExternalNode{
+			Name:      peer.Name,
+			URL:       peer.ApiUrl,
+			MSPID:     peer.MspId,
+			TLSCACert: tlsCACert,
+		})
+	}
+	for _, orderer := range assets.Orderers {
+		tlsCACert := decodeCert(orderer.TlsCaRootCert)
+		if tlsCACert == "" && len(orderer.Msp.TLSCA.RootCerts) > 0 {
+			tlsCACert = decodeCert(orderer.Msp.TLSCA.RootCerts[0])
+		}
+		externalNodes.Orderers = append(externalNodes.Orderers, helpers.ExternalNode{
+			Name:      orderer.Name,
+			URL:       orderer.ApiUrl,
+			MSPID:     orderer.MspId,
+			TLSCACert: tlsCACert,
+		})
+	}
+	for _, nodes := range [][]helpers.ExternalNode{externalNodes.Peers, externalNodes.Orderers} {
+		for _, node := range nodes {
+			err := node.Validate()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return externalNodes, nil
+}
+
+// getOrgMSPs builds the MSP of every organization, the organizations exported by the console are
+// preferred and the root certs of the nodes are used for the ones that weren't exported. Only the
+// peers listed in anchorPeers are declared as anchor peers of their organization
+func getOrgMSPs(assets *consoleAssets, anchorPeers []string) (map[string]*orgMSP, error) {
+	orgs := map[string]*orgMSP{}
+	for _, org := range assets.Orgs {
+		msp := getOrgMSP(orgs, org.MspId)
+		msp.RootCerts = decodeCerts(org.RootCerts)
+		msp.TLSRootCerts = decodeCerts(org.TlsRootCerts)
+		msp.Admins = decodeCerts(org.Admins)
+		msp.NodeOUs = org.FabricNodeOus.Enable
+	}
+	for _, peer := range assets.Peers {
+		msp := getOrgMSP(orgs, peer.MspId)
+		if len(msp.RootCerts) == 0 {
+			msp.RootCerts = decodeCerts(peer.Msp.CA.RootCerts)
+			msp.TLSRootCerts = decodeCerts(peer.Msp.TLSCA.RootCerts)
+			msp.NodeOUs = true
+		}
+		if !utils.Contains(anchorPeers, peer.Name) {
+			continue
+		}
+		peerURL, err := url.Parse(peer.ApiUrl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid url %s for peer %s", peer.ApiUrl, peer.Name)
+		}
+		port, err := strconv.Atoi(peerURL.Port())
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid port in url %s for peer %s", peer.ApiUrl, peer.Name)
+		}
+		msp.AnchorPeers = append(msp.AnchorPeers, anchorPeer{Host: peerURL.Hostname(), Port: port})
+	}
+	for _, name := range anchorPeers {
+		found := false
+		for _, peer := range assets.Peers {
+			found = found || peer.Name == name
+		}
+		if !found {
+			return nil, errors.Errorf("anchor peer %s not found in the export", name)
+		}
+	}
+	for _, orderer := range assets.Orderers {
+		msp := getOrgMSP(orgs, orderer.MspId)
+		if len(msp.RootCerts) == 0 {
+			msp.RootCerts = decodeCerts(orderer.Msp.CA.RootCerts)
+			msp.TLSRootCerts = decodeCerts(orderer.Msp.TLSCA.RootCerts)
+			msp.NodeOUs = true
+		}
+	}
+	for mspID, msp := range orgs {
+		if len(msp.RootCerts) == 0 {
+			return nil, errors.Errorf("no root certificates found for organization %s", mspID)
+		}
+	}
+	return orgs, nil
+}
+
+func writeCerts(dir string, prefix string, certs []string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for idx, cert := range certs {
+		err = ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%s-%d.pem", prefix, idx)), []byte(cert), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMSP writes the MSP directory of the organization in the layout expected by configtxgen
+func writeMSP(mspDir string, msp *orgMSP) error {
+	err := writeCerts(filepath.Join(mspDir, "cacerts"), "ca", msp.RootCerts)
+	if err != nil {
+		return err
+	}
+	err = writeCerts(filepath.Join(mspDir, "tlscacerts"), "tlsca", msp.TLSRootCerts)
+	if err != nil {
+		return err
+	}
+	err = writeCerts(filepath.Join(mspDir, "admincerts"), "admin", msp.Admins)
+	if err != nil {
+		return err
+	}
+	if !msp.NodeOUs {
+		return nil
+	}
+	caCert := "cacerts/ca-0.pem"
+	config := mspConfig{
+		NodeOUs: nodeOUs{
+			Enable:              true,
+			ClientOUIdentifier:  nodeOUIdentifier{Certificate: caCert, OrganizationalUnitIdentifier: "client"},
+			PeerOUIdentifier:    nodeOUIdentifier{Certificate: caCert, OrganizationalUnitIdentifier: "peer"},
+			AdminOUIdentifier:   nodeOUIdentifier{Certificate: caCert, OrganizationalUnitIdentifier: "admin"},
+			OrdererOUIdentifier: nodeOUIdentifier{Certificate: caCert, OrganizationalUnitIdentifier: "orderer"},
+		},
+	}
+	configBytes, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(mspDir, "config.yaml"), configBytes, 0644)
+}
+
+func newConfigtxOrganization(msp *orgMSP, mspDir string) configtxOrganization {
+	policies := map[string]configtxPolicy{
+		"Readers":     {Type: "Signature", Rule: fmt.Sprintf("OR('%s.member')", msp.MSPID)},
+		"Writers":     {Type: "Signature", Rule: fmt.Sprintf("OR('%s.member')", msp.MSPID)},
+		"Admins":      {Type: "Signature", Rule: fmt.Sprintf("OR('%s.admin')", msp.MSPID)},
+		"Endorsement": {Type: "Signature", Rule: fmt.Sprintf("OR('%s.member')", msp.MSPID)},
+	}
+	if msp.NodeOUs {
+		policies["Readers"] = configtxPolicy{Type: "Signature", Rule: fmt.Sprintf("OR('%s.admin', '%s.peer', '%s.client')", msp.MSPID, msp.MSPID, msp.MSPID)}
+		policies["Writers"] = configtxPolicy{Type: "Signature", Rule: fmt.Sprintf("OR('%s.admin', '%s.client')", msp.MSPID, msp.MSPID)}
+		policies["Endorsement"] = configtxPolicy{Type: "Signature", Rule: fmt.Sprintf("OR('%s.peer')", msp.MSPID)}
+	}
+	return configtxOrganization{
+		Name:        msp.MSPID,
+		ID:          msp.MSPID,
+		MSPDir:      mspDir,
+		MSPType:     "bccsp",
+		Policies:    policies,
+		AnchorPeers: msp.AnchorPeers,
+	}
+}
+
+// addToNetworkConfig adds the external nodes to the FabricNetworkConfig, nodes already present
+// are updated
+func (c *importFopCmd) addToNetworkConfig(externalNodes *helpers.ExternalNodes) error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	networkConfig, err := oclient.HlfV1alpha1().FabricNetworkConfigs(c.namespace).Get(ctx, c.networkConfig, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	current, err := helpers.GetNetworkConfigExternalNodes(networkConfig)
+	if err != nil {
+		return err
+	}
+	merged := &helpers.ExternalNodes{}
+	for _, peer := range current.Peers {
+		imported := false
+		for _, node := range externalNodes.Peers {
+			imported = imported || node.Name == peer.Name
+		}
+		if !imported {
+			merged.Peers = append(merged.Peers, peer)
+		}
+	}
+	merged.Peers = append(merged.Peers, externalNodes.Peers...)
+	for _, orderer := range current.Orderers {
+		imported := false
+		for _, node := range externalNodes.Orderers {
+			imported = imported || node.Name == orderer.Name
+		}
+		if !imported {
+			merged.Orderers = append(merged.Orderers, orderer)
+		}
+	}
+	merged.Orderers = append(merged.Orderers, externalNodes.Orderers...)
+	err = helpers.SetNetworkConfigExternalNodes(networkConfig, merged)
+	if err != nil {
+		return err
+	}
+	_, err = oclient.HlfV1alpha1().FabricNetworkConfigs(c.namespace).Update(ctx, networkConfig, v1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("Network Config %s updated with %d peers and %d orderers", c.networkConfig, len(externalNodes.Peers), len(externalNodes.Orderers))
+	return nil
+}
+
+func (c *importFopCmd) run() error {
+	assets, err := readConsoleZip(c.zipPath)
+	if err != nil {
+		return err
+	}
+	externalNodes, err := getExternalNodes(assets)
+	if err != nil {
+		return err
+	}
+	orgs, err := getOrgMSPs(assets, c.anchorPeers)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(c.outputDir, 0755)
+	if err != nil {
+		return err
+	}
+	externalNodesBytes, err := sigsyaml.Marshal(externalNodes)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(c.outputDir, externalNodesFile), externalNodesBytes, 0644)
+	if err != nil {
+		return err
+	}
+	var mspIDs []string
+	for mspID := range orgs {
+		mspIDs = append(mspIDs, mspID)
+	}
+	sort.Strings(mspIDs)
+	topLevel := configtx{}
+	for _, mspID := range mspIDs {
+		mspDir, err := filepath.Abs(filepath.Join(c.outputDir, mspID, "msp"))
+		if err != nil {
+			return err
+		}
+		err = writeMSP(mspDir, orgs[mspID])
+		if err != nil {
+			return err
+		}
+		topLevel.Organizations = append(topLevel.Organizations, newConfigtxOrganization(orgs[mspID], mspDir))
+	}
+	configtxBytes, err := yaml.Marshal(topLevel)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(c.outputDir, configtxFile), configtxBytes, 0644)
+	if err != nil {
+		return err
+	}
+	var data [][]string
+	for _, ca := range assets.CAs {
+		data = append(data, []string{"CA", ca.Name, "", ca.ApiUrl})
+	}
+	for _, peer := range externalNodes.Peers {
+		data = append(data, []string{"Peer", peer.Name, peer.MSPID, peer.URL})
+	}
+	for _, orderer := range externalNodes.Orderers {
+		data = append(data, []string{"Orderer", orderer.Name, orderer.MSPID, orderer.URL})
+	}
+	for _, mspID := range mspIDs {
+		data = append(data, []string{"Organization", mspID, mspID, ""})
+	}
+	table := tablewriter.NewWriter(c.out)
+	table.SetHeader([]string{"Type", "Name", "MSP ID", "URL"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	log.Infof("External nodes written to %s", filepath.Join(c.outputDir, externalNodesFile))
+	log.Infof("Organizations written to %s", filepath.Join(c.outputDir, configtxFile))
+	if c.networkConfig != "" {
+		return c.addToNetworkConfig(externalNodes)
+	}
+	return nil
+}
+
+func NewImportCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	c := &importFopCmd{out: stdOut}
+	cmd := &cobra.Command{
+		Use:     "import",
+		Short:   "Import the components of a Fabric Operations Console export",
+		Long:    importDesc,
+		Example: importExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.zipPath, "zip", "", "", "ZIP file exported from the Fabric Operations Console")
+	persistentFlags.StringVarP(&c.outputDir, "output-dir", "", "", "Directory to write the external nodes and the MSP definitions")
+	persistentFlags.StringVarP(&c.networkConfig, "networkconfig", "", "", "Network Config to add the external peers and orderers to")
+	persistentFlags.StringVarP(&c.namespace, "namespace", "n", "", "Namespace of the Network Config")
+	persistentFlags.StringSliceVarP(&c.anchorPeers, "anchor-peers", "", []string{}, "Names of the exported peers to declare as anchor peers of their organization")
+	cmd.MarkPersistentFlagRequired("zip")
+	cmd.MarkPersistentFlagRequired("output-dir")
+	return cmd
+}
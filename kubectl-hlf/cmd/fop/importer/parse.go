@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/fop/export"
+	"github.com/pkg/errors"
+)
+
+const (
+	consoleCAType      = "fabric-ca"
+	consolePeerType    = "fabric-peer"
+	consoleOrdererType = "fabric-orderer"
+	consoleMSPType     = "msp"
+)
+
+// consoleAssets are the components found in a Fabric Operations Console export
+type consoleAssets struct {
+	CAs      []export.FabricOperationsCA
+	Peers    []export.FabricOperationsPeer
+	Orderers []export.FabricOperationsOrderer
+	Orgs     []export.FabricOperationsOrg
+}
+
+// decodeCert returns the PEM of a certificate, the console stores them base64 encoded
+func decodeCert(cert string) string {
+	if cert == "" || strings.Contains(cert, "-----BEGIN") {
+		return cert
+	}
+	decoded, err := base64.StdEncoding.DecodeString(cert)
+	if err != nil {
+		return cert
+	}
+	return string(decoded)
+}
+
+// splitAssets returns the JSON objects of a file, ordering services are exported as an array of nodes
+func splitAssets(data []byte) ([]json.RawMessage, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var assets []json.RawMessage
+		err := json.Unmarshal(data, &assets)
+		if err != nil {
+			return nil, err
+		}
+		return assets, nil
+	}
+	return []json.RawMessage{data}, nil
+}
+
+func (a *consoleAssets) add(fileName string, asset json.RawMessage) error {
+	assetType := &struct {
+		Type string `json:"type"`
+	}{}
+	err := json.Unmarshal(asset, assetType)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse %s", fileName)
+	}
+	switch assetType.Type {
+	case consoleCAType:
+		ca := export.FabricOperationsCA{}
+		err = json.Unmarshal(asset, &ca)
+		a.CAs = append(a.CAs, ca)
+	case consolePeerType:
+		peer := export.FabricOperationsPeer{}
+		err = json.Unmarshal(asset, &peer)
+		a.Peers = append(a.Peers, peer)
+	case consoleOrdererType:
+		orderer := export.FabricOperationsOrderer{}
+		err = json.Unmarshal(asset, &orderer)
+		a.Orderers = append(a.Orderers, orderer)
+	case consoleMSPType:
+		org := export.FabricOperationsOrg{}
+		err = json.Unmarshal(asset, &org)
+		a.Orgs = append(a.Orgs, org)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse %s", fileName)
+	}
+	return nil
+}
+
+// readConsoleZip reads the assets of a Fabric Operations Console export, files without a known
+// type, like the /cainfo responses written by `fop export`, are ignored
+func readConsoleZip(zipPath string) (*consoleAssets, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", zipPath)
+	}
+	defer reader.Close()
+	assets := &consoleAssets{}
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || path.Ext(file.Name) != ".json" {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", file.Name, err)
+		}
+		fileAssets, err := splitAssets(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", file.Name)
+		}
+		for _, asset := range fileAssets {
+			err = assets.add(file.Name, asset)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return assets, nil
+}
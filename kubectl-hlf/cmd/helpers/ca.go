@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// IdemixCAInfo is the idemix part of the info returned by the Fabric CA, both by /cainfo and along
+// with the nonce of an idemix credential request
+type IdemixCAInfo struct {
+	CAName                    string `json:"CAName"`
+	IssuerPublicKey           []byte `json:"IssuerPublicKey"`
+	IssuerRevocationPublicKey []byte `json:"IssuerRevocationPublicKey"`
+	Version                   string `json:"Version"`
+}
+
+// NewCAHTTPClient returns a client that verifies the CA with its TLS certificate
+func NewCAHTTPClient(tlsCert string) (*http.Client, error) {
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM([]byte(tlsCert)) {
+		return nil, errors.New("failed to load the TLS certificate of the CA")
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: certPool},
+		},
+	}, nil
+}
+
+// GetIdemixCAInfo retrieves the idemix issuer public key and revocation public key of the CA
+func GetIdemixCAInfo(certAuth *ClusterCA) (*IdemixCAInfo, error) {
+	url, err := GetURLForCA(certAuth)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewCAHTTPClient(certAuth.Status.TlsCert)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid CA %s", certAuth.Name)
+	}
+	res, err := client.Get(fmt.Sprintf("%s/cainfo", url))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	bodyBytes, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	caInfoResponse := &struct {
+		Success bool         `json:"success"`
+		Result  IdemixCAInfo `json:"result"`
+	}{}
+	err = json.Unmarshal(bodyBytes, caInfoResponse)
+	if err != nil {
+		return nil, err
+	}
+	if !caInfoResponse.Success || len(caInfoResponse.Result.IssuerPublicKey) == 0 {
+		return nil, errors.Errorf("CA %s didn't return an idemix issuer public key", certAuth.Name)
+	}
+	return &caInfoResponse.Result, nil
+}
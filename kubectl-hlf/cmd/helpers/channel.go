@@ -2,7 +2,11 @@ package helpers
 
 import (
 	"bytes"
+	"fmt"
+
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-config/configtx/orderer"
 	"github.com/hyperledger/fabric-config/protolator"
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
@@ -29,6 +33,34 @@ func CreateConfigUpdateEnvelope(channelID string, configUpdate *common.ConfigUpd
 	return envelopeData, nil
 }
 
+// ComputeConfigUpdateEnvelope computes the differences between the original and the modified config and
+// wraps them in a config update envelope ready to be signed and submitted
+func ComputeConfigUpdateEnvelope(cftxGen *configtx.ConfigTx, channelID string) ([]byte, error) {
+	configUpdateBytes, err := cftxGen.ComputeMarshaledUpdate(channelID)
+	if err != nil {
+		return nil, err
+	}
+	configUpdate := &common.ConfigUpdate{}
+	err = proto.Unmarshal(configUpdateBytes, configUpdate)
+	if err != nil {
+		return nil, err
+	}
+	return CreateConfigUpdateEnvelope(channelID, configUpdate)
+}
+
+// CheckOrdererNotInMaintenance refuses orderer changes while the channel is in maintenance mode, in which
+// only the consensus type and state are expected to change
+func CheckOrdererNotInMaintenance(cftxGen *configtx.ConfigTx, channelID string) error {
+	ordConfig, err := cftxGen.Orderer().Configuration()
+	if err != nil {
+		return err
+	}
+	if ordConfig.State == orderer.ConsensusStateMaintenance {
+		return fmt.Errorf("channel %s is in maintenance mode, turn it off with `channel maintenance off` before changing the orderer config", channelID)
+	}
+	return nil
+}
+
 func GetCurrentConfigFromPeer(resClient *resmgmt.Client, channelID string) (*common.Config, error) {
 	block, err := resClient.QueryConfigBlockFromOrderer(channelID)
 	if err != nil {
@@ -0,0 +1,176 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	PeerOperationsPort    = "9443"
+	OrdererOperationsPort = "8443"
+)
+
+type VolumeUsage struct {
+	PVC           string
+	StorageClass  string
+	Requested     string
+	UsedBytes     *uint64
+	CapacityBytes *uint64
+	// Error is set when the claim couldn't be read
+	Error string
+}
+
+type CertificateHostCheck struct {
+	Host     string
+	DNSNames []string
+	IPs      []string
+	Matches  bool
+}
+
+type statsSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Volume []struct {
+			Name          string  `json:"name"`
+			UsedBytes     *uint64 `json:"usedBytes"`
+			CapacityBytes *uint64 `json:"capacityBytes"`
+			PVCRef        *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// GetNodePods returns the pods deployed by the operator for the peer, orderer or CA with the given name
+func GetNodePods(clientSet *kubernetes.Clientset, name string, ns string) ([]corev1.Pod, error) {
+	podList, err := clientSet.CoreV1().Pods(ns).List(context.Background(), v1.ListOptions{
+		LabelSelector: fmt.Sprintf("release=%s", name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+func GetPodRestarts(pod corev1.Pod) int32 {
+	var restarts int32
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		restarts += containerStatus.RestartCount
+	}
+	return restarts
+}
+
+func IsPodReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// GetPodVolumeUsage returns the persistent volume claims mounted by the pod, along with the usage
+// reported by the kubelet of the node the pod is running on, if available. Claims that can't be read
+// are returned with the error
+func GetPodVolumeUsage(clientSet *kubernetes.Clientset, pod corev1.Pod) []VolumeUsage {
+	ctx := context.Background()
+	summary := &statsSummary{}
+	if pod.Spec.NodeName != "" {
+		summaryBytes, err := clientSet.CoreV1().RESTClient().Get().
+			Resource("nodes").
+			Name(pod.Spec.NodeName).
+			SubResource("proxy").
+			Suffix("stats/summary").
+			DoRaw(ctx)
+		if err == nil {
+			err = json.Unmarshal(summaryBytes, summary)
+		}
+		if err != nil {
+			summary = &statsSummary{}
+		}
+	}
+	var volumes []VolumeUsage
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		claimName := volume.PersistentVolumeClaim.ClaimName
+		usage := VolumeUsage{PVC: claimName}
+		pvc, err := clientSet.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, claimName, v1.GetOptions{})
+		if err != nil {
+			usage.Error = err.Error()
+			volumes = append(volumes, usage)
+			continue
+		}
+		if pvc.Spec.StorageClassName != nil {
+			usage.StorageClass = *pvc.Spec.StorageClassName
+		}
+		if requested, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			usage.Requested = requested.String()
+		}
+		for _, podStats := range summary.Pods {
+			if podStats.PodRef.Name != pod.Name || podStats.PodRef.Namespace != pod.Namespace {
+				continue
+			}
+			for _, volumeStats := range podStats.Volume {
+				if volumeStats.PVCRef != nil && volumeStats.PVCRef.Name == claimName {
+					usage.UsedBytes = volumeStats.UsedBytes
+					usage.CapacityBytes = volumeStats.CapacityBytes
+				}
+			}
+		}
+		volumes = append(volumes, usage)
+	}
+	return volumes
+}
+
+// GetOperationsEndpoint queries the operations service of a node through the Kubernetes API server proxy
+func GetOperationsEndpoint(clientSet *kubernetes.Clientset, scheme string, name string, ns string, port string, path string) ([]byte, error) {
+	return clientSet.CoreV1().Services(ns).ProxyGet(scheme, name, port, path, nil).DoRaw(context.Background())
+}
+
+// CheckCertificateHost checks whether the certificate is valid for the given host
+func CheckCertificateHost(certPem string, host string) (*CertificateHostCheck, error) {
+	crt, err := utils.ParseX509Certificate([]byte(certPem))
+	if err != nil {
+		return nil, err
+	}
+	check := &CertificateHostCheck{
+		Host:     host,
+		DNSNames: crt.DNSNames,
+	}
+	for _, ip := range crt.IPAddresses {
+		check.IPs = append(check.IPs, ip.String())
+	}
+	if net.ParseIP(host) != nil || len(crt.DNSNames) > 0 {
+		check.Matches = crt.VerifyHostname(host) == nil
+	}
+	return check, nil
+}
+
+func FormatBytes(bytes *uint64) string {
+	if bytes == nil {
+		return "-"
+	}
+	const unit = 1024
+	if *bytes < unit {
+		return fmt.Sprintf("%dB", *bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := *bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ci", float64(*bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,245 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// ExternalNode is a peer or orderer that runs outside of the cluster
+type ExternalNode struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	MSPID     string `json:"mspid"`
+	TLSCA     string `json:"tlsca,omitempty"`
+	TLSCACert string `json:"tlsCACert,omitempty"`
+	// Channels the node joined, channels whose members are resolved from the cluster only list the
+	// external nodes that joined them
+	Channels []string `json:"channels,omitempty"`
+}
+
+// ExternalNodes is the file format accepted to load external nodes
+type ExternalNodes struct {
+	Peers    []ExternalNode `json:"peers"`
+	Orderers []ExternalNode `json:"orderers"`
+}
+
+// Validate checks that the node has a name, an MSP ID, a grpc or grpcs URL and, for grpcs, a TLS CA
+func (n *ExternalNode) Validate() error {
+	if n.Name == "" {
+		return errors.Errorf("external node without name")
+	}
+	u, err := url.Parse(n.URL)
+	if err != nil {
+		return errors.Wrapf(err, "invalid url %s for external node %s", n.URL, n.Name)
+	}
+	if u.Scheme != "grpcs" && u.Scheme != "grpc" {
+		return errors.Errorf("invalid url %s for external node %s, must be grpc:// or grpcs://", n.URL, n.Name)
+	}
+	if n.MSPID == "" {
+		return errors.Errorf("missing mspid for external node %s", n.Name)
+	}
+	if u.Scheme == "grpcs" && n.TLSCACert == "" {
+		return errors.Errorf("missing tls ca for external node %s", n.Name)
+	}
+	return nil
+}
+
+func (n *ExternalNode) loadTLSCA(dir string) error {
+	if n.TLSCA == "" {
+		return nil
+	}
+	path := n.TLSCA
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	tlsCACert, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "error reading tls ca %s for external node %s", path, n.Name)
+	}
+	n.TLSCACert = string(tlsCACert)
+	return nil
+}
+
+// ParseExternalNode parses an external node in the format
+// name=grpcs://host:port,tlsca=file,mspid=Org1MSP,channels=ch1;ch2
+func ParseExternalNode(value string) (*ExternalNode, error) {
+	chunks := strings.Split(value, ",")
+	nameURL := strings.SplitN(chunks[0], "=", 2)
+	if len(nameURL) != 2 {
+		return nil, fmt.Errorf("invalid external node %s, must be in format name=grpcs://host:port,tlsca=file,mspid=MSPID[,channels=ch1;ch2]", value)
+	}
+	node := &ExternalNode{
+		Name: nameURL[0],
+		URL:  nameURL[1],
+	}
+	for _, chunk := range chunks[1:] {
+		keyValue := strings.SplitN(chunk, "=", 2)
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("invalid option %s for external node %s", chunk, node.Name)
+		}
+		switch keyValue[0] {
+		case "tlsca":
+			node.TLSCA = keyValue[1]
+		case "mspid":
+			node.MSPID = keyValue[1]
+		case "channels":
+			node.Channels = strings.Split(keyValue[1], ";")
+		default:
+			return nil, fmt.Errorf("unknown option %s for external node %s, must be tlsca, mspid or channels", keyValue[0], node.Name)
+		}
+	}
+	err := node.loadTLSCA("")
+	if err != nil {
+		return nil, err
+	}
+	err = node.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// LoadExternalNodes reads the external peers and orderers from a YAML file, the tls ca files are
+// relative to the directory of the file
+func LoadExternalNodes(path string) (*ExternalNodes, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading external nodes file %s", path)
+	}
+	externalNodes := &ExternalNodes{}
+	err = yaml.Unmarshal(data, externalNodes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing external nodes file %s", path)
+	}
+	dir := filepath.Dir(path)
+	for _, nodes := range [][]ExternalNode{externalNodes.Peers, externalNodes.Orderers} {
+		for idx := range nodes {
+			err = nodes[idx].loadTLSCA(dir)
+			if err != nil {
+				return nil, err
+			}
+			err = nodes[idx].Validate()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return externalNodes, nil
+}
+
+// GetExternalNodes merges the external nodes of the file with the ones passed as flags
+func GetExternalNodes(file string, peers []string, orderers []string) (*ExternalNodes, error) {
+	externalNodes := &ExternalNodes{}
+	if file != "" {
+		var err error
+		externalNodes, err = LoadExternalNodes(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, peer := range peers {
+		node, err := ParseExternalNode(peer)
+		if err != nil {
+			return nil, err
+		}
+		externalNodes.Peers = append(externalNodes.Peers, *node)
+	}
+	for _, orderer := range orderers {
+		node, err := ParseExternalNode(orderer)
+		if err != nil {
+			return nil, err
+		}
+		externalNodes.Orderers = append(externalNodes.Orderers, *node)
+	}
+	return externalNodes, nil
+}
+
+// ExternalNodesAnnotation keeps the MSP ID and the channels of the external nodes of a FabricNetworkConfig,
+// its spec only holds their name, url and TLS CA certificate
+const ExternalNodesAnnotation = "hlf.kungfusoftware.es/external-nodes"
+
+type externalNodeMeta struct {
+	MSPID    string   `json:"mspid"`
+	Channels []string `json:"channels,omitempty"`
+}
+
+type externalNodesMeta struct {
+	Peers    map[string]externalNodeMeta `json:"peers"`
+	Orderers map[string]externalNodeMeta `json:"orderers"`
+}
+
+// SetNetworkConfigExternalNodes replaces the external nodes of the FabricNetworkConfig
+func SetNetworkConfigExternalNodes(networkConfig *hlfv1alpha1.FabricNetworkConfig, externalNodes *ExternalNodes) error {
+	meta := externalNodesMeta{
+		Peers:    map[string]externalNodeMeta{},
+		Orderers: map[string]externalNodeMeta{},
+	}
+	externalPeers := []hlfv1alpha1.FabricNetworkConfigExternalPeer{}
+	for _, peer := range externalNodes.Peers {
+		externalPeers = append(externalPeers, hlfv1alpha1.FabricNetworkConfigExternalPeer{
+			Name:      peer.Name,
+			URL:       peer.URL,
+			TLSCACert: peer.TLSCACert,
+		})
+		meta.Peers[peer.Name] = externalNodeMeta{MSPID: peer.MSPID, Channels: peer.Channels}
+	}
+	externalOrderers := []hlfv1alpha1.FabricNetworkConfigExternalOrderer{}
+	for _, orderer := range externalNodes.Orderers {
+		externalOrderers = append(externalOrderers, hlfv1alpha1.FabricNetworkConfigExternalOrderer{
+			Name:      orderer.Name,
+			URL:       orderer.URL,
+			TLSCACert: orderer.TLSCACert,
+		})
+		meta.Orderers[orderer.Name] = externalNodeMeta{MSPID: orderer.MSPID, Channels: orderer.Channels}
+	}
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if networkConfig.Annotations == nil {
+		networkConfig.Annotations = map[string]string{}
+	}
+	networkConfig.Annotations[ExternalNodesAnnotation] = string(metaBytes)
+	networkConfig.Spec.ExternalPeers = externalPeers
+	networkConfig.Spec.ExternalOrderers = externalOrderers
+	return nil
+}
+
+// GetNetworkConfigExternalNodes returns the external nodes of the FabricNetworkConfig
+func GetNetworkConfigExternalNodes(networkConfig *hlfv1alpha1.FabricNetworkConfig) (*ExternalNodes, error) {
+	meta := externalNodesMeta{}
+	if metaJSON, ok := networkConfig.Annotations[ExternalNodesAnnotation]; ok {
+		err := json.Unmarshal([]byte(metaJSON), &meta)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s annotation on network config %s", ExternalNodesAnnotation, networkConfig.Name)
+		}
+	}
+	externalNodes := &ExternalNodes{}
+	for _, peer := range networkConfig.Spec.ExternalPeers {
+		externalNodes.Peers = append(externalNodes.Peers, ExternalNode{
+			Name:      peer.Name,
+			URL:       peer.URL,
+			MSPID:     meta.Peers[peer.Name].MSPID,
+			TLSCACert: peer.TLSCACert,
+			Channels:  meta.Peers[peer.Name].Channels,
+		})
+	}
+	for _, orderer := range networkConfig.Spec.ExternalOrderers {
+		externalNodes.Orderers = append(externalNodes.Orderers, ExternalNode{
+			Name:      orderer.Name,
+			URL:       orderer.URL,
+			MSPID:     meta.Orderers[orderer.Name].MSPID,
+			TLSCACert: orderer.TLSCACert,
+			Channels:  meta.Orderers[orderer.Name].Channels,
+		})
+	}
+	return externalNodes, nil
+}
@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/networkconfig"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 
 	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
@@ -353,6 +356,46 @@ func GetURLForCA(certAuth *ClusterCA) (string, error) {
 	}
 	return fmt.Sprintf("https://%s:%d", host, port), nil
 }
+
+// CAHostMatches checks if the CA host used to enroll a component, either <name>.<namespace>, the name
+// or one of the hosts of the CA, belongs to the CA
+func CAHostMatches(certAuth *ClusterCA, caHost string) bool {
+	name := caHost
+	ns := ""
+	if chunks := strings.Split(caHost, "."); len(chunks) == 2 {
+		name = chunks[0]
+		ns = chunks[1]
+	}
+	if certAuth.Object.Name == name && (ns == "" || certAuth.Object.Namespace == ns) {
+		return true
+	}
+	return utils.Contains(certAuth.Spec.Hosts, caHost)
+}
+
+// keys of the secret where the operator stores the credentials of a FabricIdentity
+const (
+	FabricIdentitySecretCertKey = "cert.pem"
+	FabricIdentitySecretKeyKey  = "key.pem"
+	FabricIdentitySecretRootKey = "root.pem"
+	FabricIdentitySecretUserKey = "user.yaml"
+)
+
+// GetFabricIdentityUser parses the credentials the operator stored for a FabricIdentity in its secret,
+// either as a user.yaml or as separate cert.pem and key.pem entries
+func GetFabricIdentityUser(secret *corev1.Secret) (*networkconfig.User, error) {
+	user := &networkconfig.User{}
+	if userYaml, ok := secret.Data[FabricIdentitySecretUserKey]; ok {
+		err := yaml.Unmarshal(userYaml, user)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the user of identity %s.%s", secret.Name, secret.Namespace)
+		}
+		return user, nil
+	}
+	user.Cert.Pem = string(secret.Data[FabricIdentitySecretCertKey])
+	user.Key.Pem = string(secret.Data[FabricIdentitySecretKeyKey])
+	return user, nil
+}
+
 func GetCertAuthByName(clientSet *kubernetes.Clientset, oclient *operatorv1.Clientset, name string, ns string) (*ClusterCA, error) {
 	certAuths, err := GetClusterCAs(clientSet, oclient, "")
 	if err != nil {
@@ -0,0 +1,150 @@
+package networkconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// DiffStale is an entry whose value in the stored config is outdated, e.g. a renewed TLS cert
+	DiffStale = "stale"
+	// DiffMissing is an entry that exists in the cluster but not in the stored config
+	DiffMissing = "missing"
+	// DiffExtra is an entry of the stored config that no longer exists in the cluster
+	DiffExtra = "extra"
+)
+
+// Difference is a drift between the stored network config and the expected one
+type Difference struct {
+	Section string
+	Name    string
+	Field   string
+	Status  string
+}
+
+func samePem(a string, b string) bool {
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
+}
+
+func samePemList(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if !samePem(a[idx], b[idx]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(keys map[string]bool) []string {
+	var sorted []string
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func diffNodes(section string, stored map[string]Node, expected map[string]Node) []Difference {
+	names := map[string]bool{}
+	for name := range stored {
+		names[name] = true
+	}
+	for name := range expected {
+		names[name] = true
+	}
+	var differences []Difference
+	for _, name := range sortedKeys(names) {
+		storedNode, inStored := stored[name]
+		expectedNode, inExpected := expected[name]
+		switch {
+		case !inStored:
+			differences = append(differences, Difference{Section: section, Name: name, Status: DiffMissing})
+		case !inExpected:
+			differences = append(differences, Difference{Section: section, Name: name, Status: DiffExtra})
+		default:
+			if storedNode.URL != expectedNode.URL {
+				differences = append(differences, Difference{Section: section, Name: name, Field: "url", Status: DiffStale})
+			}
+			if !samePem(storedNode.TLSCACerts.Pem, expectedNode.TLSCACerts.Pem) {
+				differences = append(differences, Difference{Section: section, Name: name, Field: "tlsCACerts", Status: DiffStale})
+			}
+		}
+	}
+	return differences
+}
+
+func diffCAs(stored map[string]CertificateAuthority, expected map[string]CertificateAuthority) []Difference {
+	names := map[string]bool{}
+	for name := range stored {
+		names[name] = true
+	}
+	for name := range expected {
+		names[name] = true
+	}
+	var differences []Difference
+	for _, name := range sortedKeys(names) {
+		storedCA, inStored := stored[name]
+		expectedCA, inExpected := expected[name]
+		// the CAs listed depend on the generator, only the ones in both configs are compared
+		if !inStored || !inExpected {
+			continue
+		}
+		if storedCA.URL != expectedCA.URL {
+			differences = append(differences, Difference{Section: "certificateAuthorities", Name: name, Field: "url", Status: DiffStale})
+		}
+		if !samePemList(storedCA.TLSCACerts.Pem, expectedCA.TLSCACerts.Pem) {
+			differences = append(differences, Difference{Section: "certificateAuthorities", Name: name, Field: "tlsCACerts", Status: DiffStale})
+		}
+	}
+	return differences
+}
+
+func diffUsers(stored map[string]Organization, expected map[string]Organization) []Difference {
+	users := map[string]bool{}
+	storedUsers := map[string]User{}
+	expectedUsers := map[string]User{}
+	for mspID, org := range stored {
+		for name, user := range org.Users {
+			key := fmt.Sprintf("%s/%s", mspID, name)
+			users[key] = true
+			storedUsers[key] = user
+		}
+	}
+	for mspID, org := range expected {
+		for name, user := range org.Users {
+			key := fmt.Sprintf("%s/%s", mspID, name)
+			users[key] = true
+			expectedUsers[key] = user
+		}
+	}
+	var differences []Difference
+	for _, key := range sortedKeys(users) {
+		storedUser, inStored := storedUsers[key]
+		expectedUser, inExpected := expectedUsers[key]
+		switch {
+		case !inStored:
+			differences = append(differences, Difference{Section: "users", Name: key, Status: DiffMissing})
+		case !inExpected:
+			differences = append(differences, Difference{Section: "users", Name: key, Status: DiffExtra})
+		case !samePem(storedUser.Cert.Pem, expectedUser.Cert.Pem):
+			differences = append(differences, Difference{Section: "users", Name: key, Field: "cert", Status: DiffStale})
+		}
+	}
+	return differences
+}
+
+// Diff compares the stored network config with the expected one, it only covers what every generator
+// renders the same way: the URLs and TLS CAs of the nodes and CAs and the certificates of the users.
+// Channel members and the CAs of each organization depend on the generator and aren't compared.
+func Diff(stored *NetworkConfig, expected *NetworkConfig) []Difference {
+	var differences []Difference
+	differences = append(differences, diffNodes("peers", stored.Peers, expected.Peers)...)
+	differences = append(differences, diffNodes("orderers", stored.Orderers, expected.Orderers)...)
+	differences = append(differences, diffCAs(stored.CertificateAuthorities, expected.CertificateAuthorities)...)
+	differences = append(differences, diffUsers(stored.Organizations, expected.Organizations)...)
+	return differences
+}
@@ -0,0 +1,70 @@
+package networkconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffNodes(t *testing.T) {
+	stored := New("test")
+	stored.Peers["org1-peer0.default"] = Node{URL: "grpcs://peer0:443", TLSCACerts: Pem{Pem: "tlsca\n"}}
+	stored.Peers["org1-peer1.default"] = Node{URL: "grpcs://peer1:443", TLSCACerts: Pem{Pem: "tlsca"}}
+	stored.Orderers["ord-node1.default"] = Node{URL: "grpcs://orderer0:443"}
+	expected := New("test")
+	expected.Peers["org1-peer0.default"] = Node{URL: "grpcs://peer0:443", TLSCACerts: Pem{Pem: "tlsca"}}
+	expected.Peers["org1-peer1.default"] = Node{URL: "grpcs://peer1:7051", TLSCACerts: Pem{Pem: "renewed-tlsca"}}
+	expected.Peers["org1-peer2.default"] = Node{URL: "grpcs://peer2:443"}
+
+	want := []Difference{
+		{Section: "peers", Name: "org1-peer1.default", Field: "url", Status: DiffStale},
+		{Section: "peers", Name: "org1-peer1.default", Field: "tlsCACerts", Status: DiffStale},
+		{Section: "peers", Name: "org1-peer2.default", Status: DiffMissing},
+		{Section: "orderers", Name: "ord-node1.default", Status: DiffExtra},
+	}
+	if differences := Diff(stored, expected); !reflect.DeepEqual(differences, want) {
+		t.Errorf("Diff() = %+v, want %+v", differences, want)
+	}
+}
+
+func TestDiffUsers(t *testing.T) {
+	stored := New("test")
+	stored.Organizations["Org1MSP"] = Organization{Users: map[string]User{
+		"admin": {Cert: Pem{Pem: "admin-cert"}},
+		"old":   {Cert: Pem{Pem: "old-cert"}},
+	}}
+	expected := New("test")
+	expected.Organizations["Org1MSP"] = Organization{Users: map[string]User{
+		"admin": {Cert: Pem{Pem: "renewed-cert"}},
+		"new":   {Cert: Pem{Pem: "new-cert"}},
+	}}
+
+	want := []Difference{
+		{Section: "users", Name: "Org1MSP/admin", Field: "cert", Status: DiffStale},
+		{Section: "users", Name: "Org1MSP/new", Status: DiffMissing},
+		{Section: "users", Name: "Org1MSP/old", Status: DiffExtra},
+	}
+	if differences := Diff(stored, expected); !reflect.DeepEqual(differences, want) {
+		t.Errorf("Diff() = %+v, want %+v", differences, want)
+	}
+}
+
+func TestDiffIgnoresGeneratorSpecificSections(t *testing.T) {
+	stored := New("test")
+	stored.CertificateAuthorities["org1-ca.default"] = CertificateAuthority{URL: "https://org1-ca:443"}
+	stored.Channels["demo"] = Channel{Peers: map[string]ChannelPeer{"org1-peer0.default": AllRoles()}}
+	expected := New("test")
+	expected.CertificateAuthorities["org2-ca.default"] = CertificateAuthority{URL: "https://org2-ca:443"}
+	expected.Channels["other"] = Channel{}
+
+	if differences := Diff(stored, expected); len(differences) != 0 {
+		t.Errorf("Diff() = %+v, want no differences", differences)
+	}
+
+	expected.CertificateAuthorities["org1-ca.default"] = CertificateAuthority{URL: "https://org1-ca:7054"}
+	want := []Difference{
+		{Section: "certificateAuthorities", Name: "org1-ca.default", Field: "url", Status: DiffStale},
+	}
+	if differences := Diff(stored, expected); !reflect.DeepEqual(differences, want) {
+		t.Errorf("Diff() = %+v, want %+v", differences, want)
+	}
+}
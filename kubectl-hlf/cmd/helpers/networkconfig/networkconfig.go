@@ -0,0 +1,201 @@
+package networkconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// New returns an empty network config, maps are initialized so that they're rendered as {} instead of null
+func New(name string) *NetworkConfig {
+	return &NetworkConfig{
+		Name:                   name,
+		Version:                "1.0.0",
+		Organizations:          map[string]Organization{},
+		Orderers:               map[string]Node{},
+		Peers:                  map[string]Node{},
+		CertificateAuthorities: map[string]CertificateAuthority{},
+		Channels:               map[string]Channel{},
+	}
+}
+
+// AllRoles returns the roles of a peer that takes part in every activity of the channel
+func AllRoles() ChannelPeer {
+	enabled := true
+	return ChannelPeer{
+		Discover:       &enabled,
+		EndorsingPeer:  &enabled,
+		ChaincodeQuery: &enabled,
+		LedgerQuery:    &enabled,
+		EventSource:    &enabled,
+	}
+}
+
+// mapSections are the sections that older versions of inspect rendered as [] when empty
+var mapSections = []string{"organizations", "orderers", "peers", "certificateAuthorities", "channels"}
+
+// Unmarshal reads a network config, empty sections written as [] instead of {} are accepted
+func Unmarshal(data []byte) (*NetworkConfig, error) {
+	raw := map[string]interface{}{}
+	err := yaml.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, section := range mapSections {
+		if list, ok := raw[section].([]interface{}); ok && len(list) == 0 {
+			raw[section] = map[string]interface{}{}
+		}
+	}
+	normalized, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	networkConfig := New("")
+	err = yaml.Unmarshal(normalized, networkConfig)
+	if err != nil {
+		return nil, err
+	}
+	return networkConfig, nil
+}
+
+// Marshal renders the network config as YAML, PEMs are rendered as literal blocks
+func (n *NetworkConfig) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err := encoder.Encode(n)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalIndentJSON renders the network config as JSON
+func (n *NetworkConfig) MarshalIndentJSON() ([]byte, error) {
+	return json.MarshalIndent(n, "", "  ")
+}
+
+// AddUser adds or replaces a user of an organization
+func (n *NetworkConfig) AddUser(mspID string, userName string, user User) error {
+	org, ok := n.Organizations[mspID]
+	if !ok {
+		var mspIDs []string
+		for orgMSPID := range n.Organizations {
+			mspIDs = append(mspIDs, orgMSPID)
+		}
+		sort.Strings(mspIDs)
+		return errors.Errorf("organization %s not found in the network config, available organizations: %s", mspID, strings.Join(mspIDs, ", "))
+	}
+	if org.Users == nil {
+		org.Users = map[string]User{}
+	}
+	org.Users[userName] = user
+	n.Organizations[mspID] = org
+	return nil
+}
+
+func validateURL(rawURL string, schemes ...string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			if u.Host == "" {
+				return errors.Errorf("missing host")
+			}
+			return nil
+		}
+	}
+	return errors.Errorf("invalid scheme %q, expected one of %s", u.Scheme, strings.Join(schemes, ", "))
+}
+
+func validateNode(kind string, name string, node Node) []string {
+	var problems []string
+	if err := validateURL(node.URL, "grpc", "grpcs"); err != nil {
+		problems = append(problems, fmt.Sprintf("%s %s has an invalid url %s: %v", kind, name, node.URL, err))
+	} else if strings.HasPrefix(node.URL, "grpcs://") && node.TLSCACerts.Pem == "" && node.TLSCACerts.Path == "" {
+		problems = append(problems, fmt.Sprintf("%s %s uses TLS but has no TLS CA certificate", kind, name))
+	}
+	return problems
+}
+
+// Validate checks that every referenced peer, orderer and CA exists, that every user has both a
+// certificate and a key and that every URL has a valid scheme
+func (n *NetworkConfig) Validate() error {
+	problems := n.Problems()
+	if len(problems) > 0 {
+		return errors.Errorf("invalid network config:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Problems returns, sorted, the problems found by Validate
+func (n *NetworkConfig) Problems() []string {
+	var problems []string
+	if n.Client.Organization != "" {
+		if _, ok := n.Organizations[n.Client.Organization]; !ok {
+			problems = append(problems, fmt.Sprintf("client organization %s not found", n.Client.Organization))
+		}
+	}
+	for mspID, org := range n.Organizations {
+		for _, peer := range org.Peers {
+			if _, ok := n.Peers[peer]; !ok {
+				problems = append(problems, fmt.Sprintf("organization %s references unknown peer %s", mspID, peer))
+			}
+		}
+		for _, orderer := range org.Orderers {
+			if _, ok := n.Orderers[orderer]; !ok {
+				problems = append(problems, fmt.Sprintf("organization %s references unknown orderer %s", mspID, orderer))
+			}
+		}
+		for _, certAuth := range org.CertificateAuthorities {
+			if _, ok := n.CertificateAuthorities[certAuth]; !ok {
+				problems = append(problems, fmt.Sprintf("organization %s references unknown certificate authority %s", mspID, certAuth))
+			}
+		}
+		for userName, user := range org.Users {
+			if user.Cert.Pem == "" && user.Cert.Path == "" {
+				problems = append(problems, fmt.Sprintf("user %s of organization %s has no certificate", userName, mspID))
+			}
+			if user.Key.Pem == "" && user.Key.Path == "" {
+				problems = append(problems, fmt.Sprintf("user %s of organization %s has no private key", userName, mspID))
+			}
+		}
+	}
+	for name, peer := range n.Peers {
+		problems = append(problems, validateNode("peer", name, peer)...)
+	}
+	for name, orderer := range n.Orderers {
+		problems = append(problems, validateNode("orderer", name, orderer)...)
+	}
+	for name, certAuth := range n.CertificateAuthorities {
+		if err := validateURL(certAuth.URL, "http", "https"); err != nil {
+			problems = append(problems, fmt.Sprintf("certificate authority %s has an invalid url %s: %v", name, certAuth.URL, err))
+		}
+	}
+	for channelName, channel := range n.Channels {
+		for _, orderer := range channel.Orderers {
+			if _, ok := n.Orderers[orderer]; !ok {
+				problems = append(problems, fmt.Sprintf("channel %s references unknown orderer %s", channelName, orderer))
+			}
+		}
+		for peer := range channel.Peers {
+			if _, ok := n.Peers[peer]; !ok {
+				problems = append(problems, fmt.Sprintf("channel %s references unknown peer %s", channelName, peer))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
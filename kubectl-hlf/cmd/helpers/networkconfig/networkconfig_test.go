@@ -0,0 +1,306 @@
+package networkconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const validConfig = `name: test
+version: 1.0.0
+client:
+  organization: Org1MSP
+organizations:
+  Org1MSP:
+    mspid: Org1MSP
+    peers:
+      - org1-peer0.default
+    orderers:
+      - ord-node1.default
+    certificateAuthorities:
+      - org1-ca.default
+    users:
+      admin:
+        cert:
+          pem: cert
+        key:
+          pem: key
+peers:
+  org1-peer0.default:
+    url: grpcs://peer0.org1:443
+    tlsCACerts:
+      pem: tlsca
+orderers:
+  ord-node1.default:
+    url: grpcs://orderer0:443
+    tlsCACerts:
+      path: /tmp/orderer-tlsca.pem
+certificateAuthorities:
+  org1-ca.default:
+    url: https://org1-ca:443
+    tlsCACerts:
+      pem:
+        - tlsca
+channels:
+  demo:
+    orderers:
+      - ord-node1.default
+    peers:
+      org1-peer0.default:
+        endorsingPeer: true
+`
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+		check   func(t *testing.T, n *NetworkConfig)
+	}{
+		{
+			name: "empty sections written as lists",
+			config: `name: test
+organizations: []
+orderers: []
+peers: []
+certificateAuthorities: []
+channels: []
+`,
+			check: func(t *testing.T, n *NetworkConfig) {
+				if n.Organizations == nil || n.Orderers == nil || n.Peers == nil || n.CertificateAuthorities == nil || n.Channels == nil {
+					t.Errorf("expected every section to be an empty map, got %+v", n)
+				}
+			},
+		},
+		{
+			name: "empty sections written as maps",
+			config: `name: test
+organizations: {}
+peers: {}
+`,
+			check: func(t *testing.T, n *NetworkConfig) {
+				if len(n.Organizations) != 0 || len(n.Peers) != 0 {
+					t.Errorf("expected empty sections, got %+v", n)
+				}
+			},
+		},
+		{
+			name:    "non empty list section",
+			config:  "peers:\n  - peer0\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			config:  "peers: [",
+			wantErr: true,
+		},
+		{
+			name: "unmodeled keys are kept",
+			config: `name: test
+entityMatchers:
+  peer: []
+peers:
+  peer0:
+    url: grpcs://peer0:443
+    grpcOptions:
+      keep-alive-time: 10s
+      fail-fast: false
+    tlsCACerts:
+      path: /tmp/tlsca.pem
+certificateAuthorities:
+  ca:
+    url: https://ca:443
+    httpOptions:
+      verify: true
+channels:
+  demo:
+    policies:
+      queryChannelConfig:
+        minResponses: 1
+`,
+			check: func(t *testing.T, n *NetworkConfig) {
+				if _, ok := n.Extra["entityMatchers"]; !ok {
+					t.Errorf("entityMatchers not kept")
+				}
+				peer := n.Peers["peer0"]
+				if peer.TLSCACerts.Path != "/tmp/tlsca.pem" {
+					t.Errorf("tlsCACerts.path not kept, got %+v", peer.TLSCACerts)
+				}
+				if peer.GRPCOptions.Extra["keep-alive-time"] != "10s" || peer.GRPCOptions.Extra["fail-fast"] != false {
+					t.Errorf("grpcOptions not kept, got %+v", peer.GRPCOptions.Extra)
+				}
+				if _, ok := n.CertificateAuthorities["ca"].Extra["httpOptions"]; !ok {
+					t.Errorf("httpOptions not kept")
+				}
+				if _, ok := n.Channels["demo"].Extra["policies"]; !ok {
+					t.Errorf("channel policies not kept")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Unmarshal([]byte(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, n)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	n, err := Unmarshal([]byte(validConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := n.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(n, roundTrip) {
+		t.Errorf("round trip changed the network config:\n%s", string(data))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(n *NetworkConfig)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(n *NetworkConfig) {},
+		},
+		{
+			name: "unknown client organization",
+			modify: func(n *NetworkConfig) {
+				n.Client.Organization = "Org2MSP"
+			},
+			want: []string{"client organization Org2MSP not found"},
+		},
+		{
+			name: "missing references",
+			modify: func(n *NetworkConfig) {
+				delete(n.Peers, "org1-peer0.default")
+				delete(n.Orderers, "ord-node1.default")
+				delete(n.CertificateAuthorities, "org1-ca.default")
+			},
+			want: []string{
+				"channel demo references unknown orderer ord-node1.default",
+				"channel demo references unknown peer org1-peer0.default",
+				"organization Org1MSP references unknown certificate authority org1-ca.default",
+				"organization Org1MSP references unknown orderer ord-node1.default",
+				"organization Org1MSP references unknown peer org1-peer0.default",
+			},
+		},
+		{
+			name: "user without certificate or key",
+			modify: func(n *NetworkConfig) {
+				n.Organizations["Org1MSP"].Users["admin"] = User{}
+			},
+			want: []string{
+				"user admin of organization Org1MSP has no certificate",
+				"user admin of organization Org1MSP has no private key",
+			},
+		},
+		{
+			name: "user with paths",
+			modify: func(n *NetworkConfig) {
+				n.Organizations["Org1MSP"].Users["admin"] = User{Cert: Pem{Path: "cert.pem"}, Key: Pem{Path: "key.pem"}}
+			},
+		},
+		{
+			name: "bad node url schemes",
+			modify: func(n *NetworkConfig) {
+				peer := n.Peers["org1-peer0.default"]
+				peer.URL = "https://peer0.org1:443"
+				n.Peers["org1-peer0.default"] = peer
+				orderer := n.Orderers["ord-node1.default"]
+				orderer.URL = "grpcs://"
+				n.Orderers["ord-node1.default"] = orderer
+			},
+			want: []string{
+				`orderer ord-node1.default has an invalid url grpcs://: missing host`,
+				`peer org1-peer0.default has an invalid url https://peer0.org1:443: invalid scheme "https", expected one of grpc, grpcs`,
+			},
+		},
+		{
+			name: "bad CA url scheme",
+			modify: func(n *NetworkConfig) {
+				certAuth := n.CertificateAuthorities["org1-ca.default"]
+				certAuth.URL = "grpcs://org1-ca:443"
+				n.CertificateAuthorities["org1-ca.default"] = certAuth
+			},
+			want: []string{
+				`certificate authority org1-ca.default has an invalid url grpcs://org1-ca:443: invalid scheme "grpcs", expected one of http, https`,
+			},
+		},
+		{
+			name: "TLS without CA certificate",
+			modify: func(n *NetworkConfig) {
+				peer := n.Peers["org1-peer0.default"]
+				peer.TLSCACerts = Pem{}
+				n.Peers["org1-peer0.default"] = peer
+			},
+			want: []string{"peer org1-peer0.default uses TLS but has no TLS CA certificate"},
+		},
+		{
+			name: "plain grpc without CA certificate",
+			modify: func(n *NetworkConfig) {
+				peer := n.Peers["org1-peer0.default"]
+				peer.URL = "grpc://peer0.org1:7051"
+				peer.TLSCACerts = Pem{}
+				n.Peers["org1-peer0.default"] = peer
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Unmarshal([]byte(validConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(n)
+			problems := n.Problems()
+			if !reflect.DeepEqual(problems, tt.want) {
+				t.Errorf("Problems() = %q, want %q", problems, tt.want)
+			}
+			err = n.Validate()
+			if (err != nil) != (len(tt.want) > 0) {
+				t.Fatalf("Validate() error = %v, want problems %q", err, tt.want)
+			}
+			for _, problem := range tt.want {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("Validate() error doesn't contain %q", problem)
+				}
+			}
+		})
+	}
+}
+
+func TestAddUser(t *testing.T) {
+	n, err := Unmarshal([]byte(validConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := User{Cert: Pem{Pem: "new-cert"}, Key: Pem{Pem: "new-key"}}
+	err = n.AddUser("Org1MSP", "admin2", user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(n.Organizations["Org1MSP"].Users["admin2"], user) {
+		t.Errorf("user not added, got %+v", n.Organizations["Org1MSP"].Users)
+	}
+	err = n.AddUser("Org2MSP", "admin", user)
+	if err == nil || !strings.Contains(err.Error(), "available organizations: Org1MSP") {
+		t.Errorf("expected an error listing the organizations, got %v", err)
+	}
+}
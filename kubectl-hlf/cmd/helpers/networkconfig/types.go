@@ -0,0 +1,97 @@
+package networkconfig
+
+// NetworkConfig is the network configuration consumed by the fabric-sdk-go.
+type NetworkConfig struct {
+	Name                   string                          `json:"name" yaml:"name"`
+	Version                string                          `json:"version" yaml:"version"`
+	Client                 Client                          `json:"client" yaml:"client"`
+	Organizations          map[string]Organization         `json:"organizations" yaml:"organizations"`
+	Orderers               map[string]Node                 `json:"orderers" yaml:"orderers"`
+	Peers                  map[string]Node                 `json:"peers" yaml:"peers"`
+	CertificateAuthorities map[string]CertificateAuthority `json:"certificateAuthorities" yaml:"certificateAuthorities"`
+	Channels               map[string]Channel              `json:"channels" yaml:"channels"`
+	// Extra keeps the sections not modeled here, e.g. entityMatchers, when a config is read and written back
+	Extra map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// Client is the organization the SDK acts on behalf of.
+type Client struct {
+	Organization string                 `json:"organization" yaml:"organization"`
+	Extra        map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// Organization is an organization of the network, users are the identities available to the SDK.
+type Organization struct {
+	MSPID                  string                 `json:"mspid" yaml:"mspid"`
+	CryptoPath             string                 `json:"cryptoPath,omitempty" yaml:"cryptoPath,omitempty"`
+	Users                  map[string]User        `json:"users" yaml:"users"`
+	Peers                  []string               `json:"peers" yaml:"peers"`
+	Orderers               []string               `json:"orderers" yaml:"orderers"`
+	CertificateAuthorities []string               `json:"certificateAuthorities,omitempty" yaml:"certificateAuthorities,omitempty"`
+	Extra                  map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// User is an enrolled identity, it's also the format written by `ca enroll`.
+type User struct {
+	Cert  Pem                    `json:"cert" yaml:"cert"`
+	Key   Pem                    `json:"key" yaml:"key"`
+	Extra map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// Pem is either the PEM itself or the path of the file holding it.
+type Pem struct {
+	Pem  string `json:"pem,omitempty" yaml:"pem,omitempty"`
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+type PemList struct {
+	Pem  []string `json:"pem,omitempty" yaml:"pem,omitempty"`
+	Path string   `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// Node is either a peer or an orderer.
+type Node struct {
+	URL         string                 `json:"url" yaml:"url"`
+	GRPCOptions GRPCOptions            `json:"grpcOptions" yaml:"grpcOptions"`
+	TLSCACerts  Pem                    `json:"tlsCACerts" yaml:"tlsCACerts"`
+	Extra       map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// GRPCOptions are the options of the connection to a node, options not modeled here, e.g. keep-alive-time,
+// are kept in Extra.
+type GRPCOptions struct {
+	AllowInsecure         bool                   `json:"allow-insecure" yaml:"allow-insecure"`
+	SSLTargetNameOverride string                 `json:"ssl-target-name-override,omitempty" yaml:"ssl-target-name-override,omitempty"`
+	HostnameOverride      string                 `json:"hostnameOverride,omitempty" yaml:"hostnameOverride,omitempty"`
+	Extra                 map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+type CertificateAuthority struct {
+	URL        string                 `json:"url" yaml:"url"`
+	Registrar  *Registrar             `json:"registrar,omitempty" yaml:"registrar,omitempty"`
+	CAName     string                 `json:"caName,omitempty" yaml:"caName,omitempty"`
+	TLSCACerts PemList                `json:"tlsCACerts" yaml:"tlsCACerts"`
+	Extra      map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+type Registrar struct {
+	EnrollID     string                 `json:"enrollId" yaml:"enrollId"`
+	EnrollSecret string                 `json:"enrollSecret" yaml:"enrollSecret"`
+	Extra        map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+type Channel struct {
+	Orderers []string               `json:"orderers" yaml:"orderers"`
+	Peers    map[string]ChannelPeer `json:"peers" yaml:"peers"`
+	Extra    map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// ChannelPeer holds the roles of a peer in a channel, roles that aren't set default to true in the SDK.
+type ChannelPeer struct {
+	Discover       *bool                  `json:"discover,omitempty" yaml:"discover,omitempty"`
+	EndorsingPeer  *bool                  `json:"endorsingPeer,omitempty" yaml:"endorsingPeer,omitempty"`
+	ChaincodeQuery *bool                  `json:"chaincodeQuery,omitempty" yaml:"chaincodeQuery,omitempty"`
+	LedgerQuery    *bool                  `json:"ledgerQuery,omitempty" yaml:"ledgerQuery,omitempty"`
+	EventSource    *bool                  `json:"eventSource,omitempty" yaml:"eventSource,omitempty"`
+	Extra          map[string]interface{} `json:"-" yaml:",inline"`
+}
@@ -0,0 +1,236 @@
+package identity
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// attrsOID is the ASN.1 object identifier used by the Fabric CA to embed the attributes in the certificate
+var attrsOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+type certificateInfo struct {
+	Subject    string
+	OUs        []string
+	Attributes map[string]string
+	NotBefore  time.Time
+	NotAfter   time.Time
+}
+
+type identityInfo struct {
+	Identity       v1alpha1.FabricIdentity
+	SecretFound    bool
+	SecretMissing  bool
+	SecretError    string
+	Certificate    *certificateInfo
+	NetworkConfigs []string
+}
+
+func parseCertificateInfo(certPem string) (*certificateInfo, error) {
+	crt, err := parseCertificatePEM(certPem)
+	if err != nil {
+		return nil, err
+	}
+	info := &certificateInfo{
+		Subject:    crt.Subject.String(),
+		OUs:        crt.Subject.OrganizationalUnit,
+		Attributes: map[string]string{},
+		NotBefore:  crt.NotBefore,
+		NotAfter:   crt.NotAfter,
+	}
+	for _, ext := range crt.Extensions {
+		if !ext.Id.Equal(attrsOID) {
+			continue
+		}
+		attrs := &struct {
+			Attrs map[string]string `json:"attrs"`
+		}{}
+		err = json.Unmarshal(ext.Value, attrs)
+		if err != nil {
+			return nil, err
+		}
+		info.Attributes = attrs.Attrs
+	}
+	return info, nil
+}
+
+// getNetworkConfigsByIdentity returns the FabricNetworkConfig resources embedding each identity, keyed by <name>.<ns>
+func getNetworkConfigsByIdentity(oclient *operatorv1.Clientset) (map[string][]string, error) {
+	networkConfigs, err := oclient.HlfV1alpha1().FabricNetworkConfigs("").List(context.Background(), v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	networkConfigsByIdentity := map[string][]string{}
+	for _, networkConfig := range networkConfigs.Items {
+		for _, identity := range networkConfig.Spec.Identities {
+			key := fmt.Sprintf("%s.%s", identity.Name, identity.Namespace)
+			networkConfigsByIdentity[key] = append(
+				networkConfigsByIdentity[key],
+				fmt.Sprintf("%s.%s", networkConfig.Name, networkConfig.Namespace),
+			)
+		}
+	}
+	return networkConfigsByIdentity, nil
+}
+
+func getIdentityInfo(
+	clientSet *kubernetes.Clientset,
+	fabricIdentity v1alpha1.FabricIdentity,
+	networkConfigsByIdentity map[string][]string,
+) *identityInfo {
+	info := &identityInfo{
+		Identity:       fabricIdentity,
+		NetworkConfigs: networkConfigsByIdentity[fmt.Sprintf("%s.%s", fabricIdentity.Name, fabricIdentity.Namespace)],
+	}
+	secret, err := clientSet.CoreV1().Secrets(fabricIdentity.Namespace).Get(context.Background(), fabricIdentity.Name, v1.GetOptions{})
+	if err != nil {
+		info.SecretMissing = apierrors.IsNotFound(err)
+		info.SecretError = err.Error()
+		return info
+	}
+	info.SecretFound = true
+	id, err := mapSecretIdentity(secret)
+	if err != nil {
+		info.SecretError = err.Error()
+		return info
+	}
+	certInfo, err := parseCertificateInfo(id.Cert)
+	if err != nil {
+		info.SecretError = err.Error()
+		return info
+	}
+	info.Certificate = certInfo
+	return info
+}
+
+func (i identityInfo) secretStatus() string {
+	if i.SecretMissing {
+		return "Missing"
+	}
+	if !i.SecretFound {
+		return "Error"
+	}
+	if i.SecretError != "" {
+		return "Invalid"
+	}
+	return "Ready"
+}
+
+func (i identityInfo) expiry() string {
+	if i.Certificate == nil {
+		return ""
+	}
+	return i.Certificate.NotAfter.Format(time.RFC3339)
+}
+
+type describeIdentityCmd struct {
+	name      string
+	namespace string
+}
+
+func (c *describeIdentityCmd) validate() error {
+	if c.name == "" {
+		return fmt.Errorf("--name is required")
+	}
+	if c.namespace == "" {
+		return fmt.Errorf("--namespace is required")
+	}
+	return nil
+}
+
+func (c *describeIdentityCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	fabricIdentity, err := oclient.HlfV1alpha1().FabricIdentities(c.namespace).Get(context.Background(), c.name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	networkConfigsByIdentity, err := getNetworkConfigsByIdentity(oclient)
+	if err != nil {
+		return err
+	}
+	info := getIdentityInfo(clientSet, *fabricIdentity, networkConfigsByIdentity)
+	spec := fabricIdentity.Spec
+	fmt.Printf("Name:\t\t%s\n", fabricIdentity.Name)
+	fmt.Printf("Namespace:\t%s\n", fabricIdentity.Namespace)
+	fmt.Printf("MSP ID:\t\t%s\n", spec.MSPID)
+	fmt.Printf("CA:\t\t%s (%s:%d)\n", spec.Caname, spec.Cahost, spec.Caport)
+	fmt.Printf("Enroll ID:\t%s\n", spec.Enrollid)
+	fmt.Printf("Status:\t\t%s\n", fabricIdentity.Status.Status)
+	if fabricIdentity.Status.Message != "" {
+		fmt.Printf("Message:\t%s\n", fabricIdentity.Status.Message)
+	}
+	if spec.Register != nil {
+		fmt.Printf("Register:\n")
+		fmt.Printf("  Enroll ID:\t\t%s\n", spec.Register.Enrollid)
+		fmt.Printf("  Type:\t\t\t%s\n", spec.Register.Type)
+		fmt.Printf("  Affiliation:\t\t%s\n", spec.Register.Affiliation)
+		fmt.Printf("  Max Enrollments:\t%d\n", spec.Register.MaxEnrollments)
+		fmt.Printf("  Attributes:\t\t%s\n", strings.Join(spec.Register.Attrs, ","))
+	} else {
+		fmt.Printf("Register:\t<none>\n")
+	}
+	fmt.Printf("Secret:\t\t%s", info.secretStatus())
+	if info.SecretError != "" {
+		fmt.Printf(" (%s)", info.SecretError)
+	}
+	fmt.Println()
+	if info.Certificate != nil {
+		fmt.Printf("Certificate:\n")
+		fmt.Printf("  Subject:\t%s\n", info.Certificate.Subject)
+		fmt.Printf("  OUs:\t\t%s\n", strings.Join(info.Certificate.OUs, ","))
+		fmt.Printf("  Not Before:\t%s\n", info.Certificate.NotBefore.Format(time.RFC3339))
+		fmt.Printf("  Not After:\t%s\n", info.Certificate.NotAfter.Format(time.RFC3339))
+		fmt.Printf("  Attributes:\n")
+		var attrNames []string
+		for attrName := range info.Certificate.Attributes {
+			attrNames = append(attrNames, attrName)
+		}
+		sort.Strings(attrNames)
+		for _, attrName := range attrNames {
+			fmt.Printf("    %s=%s\n", attrName, info.Certificate.Attributes[attrName])
+		}
+	}
+	fmt.Printf("Network Configs:\n")
+	for _, networkConfig := range info.NetworkConfigs {
+		fmt.Printf("  %s\n", networkConfig)
+	}
+	return nil
+}
+
+func newIdentityDescribeCMD() *cobra.Command {
+	c := &describeIdentityCmd{}
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Describe HLF identity",
+		Long:  `Describe HLF identity, its generated secret and the issued certificate`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.name, "name", "", "Name of the identity")
+	f.StringVar(&c.namespace, "namespace", "", "Namespace of the identity")
+	return cmd
+}
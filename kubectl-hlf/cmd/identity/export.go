@@ -0,0 +1,537 @@
+package identity
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	identityExportDesc = `
+'export' command converts an identity between the formats used by the different Fabric SDKs and tools`
+	identityExportExample = `  kubectl hlf identity export --input-format fabricidentity --input org1-admin.default --format msp --output ./org1-admin-msp
+  kubectl hlf identity export --input-format yaml --input admin.yaml --mspid Org1MSP --format wallet --output ./wallet --wallet-user admin
+  kubectl hlf identity export --input-format wallet --input ./wallet --wallet-user admin --format pkcs12 --password changeit --output admin.p12`
+
+	yamlIdentityFormat           = "yaml"
+	mspIdentityFormat            = "msp"
+	walletIdentityFormat         = "wallet"
+	secretIdentityFormat         = "secret"
+	pkcs12IdentityFormat         = "pkcs12"
+	fabricIdentityIdentityFormat = "fabricidentity"
+)
+
+const mspNodeOUsConfig = `NodeOUs:
+  Enable: true
+  ClientOUIdentifier:
+    Certificate: cacerts/ca.pem
+    OrganizationalUnitIdentifier: client
+  PeerOUIdentifier:
+    Certificate: cacerts/ca.pem
+    OrganizationalUnitIdentifier: peer
+  AdminOUIdentifier:
+    Certificate: cacerts/ca.pem
+    OrganizationalUnitIdentifier: admin
+  OrdererOUIdentifier:
+    Certificate: cacerts/ca.pem
+    OrganizationalUnitIdentifier: orderer
+`
+
+type identity struct {
+	Cert Pem `json:"cert"`
+	Key  Pem `json:"key"`
+}
+type Pem struct {
+	Pem string
+}
+
+// exportedIdentity is the format independent representation of an identity
+type exportedIdentity struct {
+	MSPID     string
+	Cert      string
+	Key       string
+	CACert    string
+	TLSCACert string
+}
+
+type exportIdentityCmd struct {
+	input           string
+	inputFormat     string
+	output          string
+	format          string
+	mspID           string
+	walletUser      string
+	password        string
+	caCert          string
+	tlsCACert       string
+	secretName      string
+	secretNamespace string
+}
+
+func (c *exportIdentityCmd) validate() error {
+	if c.input == "" {
+		return fmt.Errorf("--input is required")
+	}
+	if c.inputFormat == secretIdentityFormat || c.inputFormat == fabricIdentityIdentityFormat {
+		if len(strings.Split(c.input, ".")) != 2 {
+			return fmt.Errorf("--input %s is not valid, must be in format <name>.<ns>", c.input)
+		}
+	}
+	if c.inputFormat == walletIdentityFormat && c.walletUser == "" {
+		return fmt.Errorf("--wallet-user is required to read from a wallet")
+	}
+	switch c.format {
+	case yamlIdentityFormat, secretIdentityFormat:
+	case mspIdentityFormat, walletIdentityFormat:
+		if c.output == "" {
+			return fmt.Errorf("--output directory is required for format %s", c.format)
+		}
+		if c.format == walletIdentityFormat && c.walletUser == "" {
+			return fmt.Errorf("--wallet-user is required to write to a wallet")
+		}
+	case pkcs12IdentityFormat:
+		if c.output == "" {
+			return fmt.Errorf("--output is required for format %s", c.format)
+		}
+	default:
+		return fmt.Errorf("invalid format %s, must be one of yaml/msp/wallet/secret/pkcs12", c.format)
+	}
+	if c.format == secretIdentityFormat && c.secretName == "" {
+		return fmt.Errorf("--secret-name is required for format %s", c.format)
+	}
+	return nil
+}
+
+func (c *exportIdentityCmd) run() error {
+	id, err := c.readIdentity()
+	if err != nil {
+		return err
+	}
+	if c.mspID != "" {
+		id.MSPID = c.mspID
+	}
+	if c.caCert != "" {
+		caCert, err := ioutil.ReadFile(c.caCert)
+		if err != nil {
+			return err
+		}
+		id.CACert = string(caCert)
+	}
+	if c.tlsCACert != "" {
+		tlsCACert, err := ioutil.ReadFile(c.tlsCACert)
+		if err != nil {
+			return err
+		}
+		id.TLSCACert = string(tlsCACert)
+	}
+	if id.Cert == "" || id.Key == "" {
+		return errors.Errorf("identity read from %s doesn't contain both certificate and private key", c.input)
+	}
+	switch c.format {
+	case yamlIdentityFormat:
+		return c.writeYAML(id)
+	case mspIdentityFormat:
+		return c.writeMSP(id)
+	case walletIdentityFormat:
+		return c.writeWallet(id)
+	case secretIdentityFormat:
+		return c.writeSecret(id)
+	case pkcs12IdentityFormat:
+		return c.writePKCS12(id)
+	}
+	return nil
+}
+
+func (c *exportIdentityCmd) readIdentity() (*exportedIdentity, error) {
+	switch c.inputFormat {
+	case yamlIdentityFormat:
+		return readYAMLIdentity(c.input)
+	case mspIdentityFormat:
+		return readMSPIdentity(c.input)
+	case walletIdentityFormat:
+		return readWalletIdentity(c.input, c.walletUser)
+	case pkcs12IdentityFormat:
+		return readPKCS12Identity(c.input, c.password)
+	case secretIdentityFormat:
+		chunks := strings.Split(c.input, ".")
+		return readSecretIdentity(chunks[0], chunks[1])
+	case fabricIdentityIdentityFormat:
+		chunks := strings.Split(c.input, ".")
+		return readFabricIdentity(chunks[0], chunks[1])
+	}
+	return nil, errors.Errorf("invalid input format %s, must be one of yaml/msp/wallet/secret/pkcs12/fabricidentity", c.inputFormat)
+}
+
+func readYAMLIdentity(filePath string) (*exportedIdentity, error) {
+	identityBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseYAMLIdentity(identityBytes)
+}
+
+func parseYAMLIdentity(identityBytes []byte) (*exportedIdentity, error) {
+	id := &identity{}
+	err := yaml.Unmarshal(identityBytes, id)
+	if err != nil {
+		return nil, err
+	}
+	return &exportedIdentity{
+		Cert: id.Cert.Pem,
+		Key:  id.Key.Pem,
+	}, nil
+}
+
+// readFirstFile returns the contents of the first file (sorted by name) found in dir
+func readFirstFile(dir string) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "", errors.Errorf("no files found in %s", dir)
+	}
+	sort.Strings(names)
+	contents, err := ioutil.ReadFile(path.Join(dir, names[0]))
+	if err != nil {
+		return "", err
+	}
+	return string(contents), nil
+}
+
+func readMSPIdentity(mspDir string) (*exportedIdentity, error) {
+	cert, err := readFirstFile(path.Join(mspDir, "signcerts"))
+	if err != nil {
+		return nil, err
+	}
+	key, err := readFirstFile(path.Join(mspDir, "keystore"))
+	if err != nil {
+		return nil, err
+	}
+	id := &exportedIdentity{
+		Cert: cert,
+		Key:  key,
+	}
+	// CA certificates are optional in the MSP directory
+	if caCert, err := readFirstFile(path.Join(mspDir, "cacerts")); err == nil {
+		id.CACert = caCert
+	}
+	if tlsCACert, err := readFirstFile(path.Join(mspDir, "tlscacerts")); err == nil {
+		id.TLSCACert = tlsCACert
+	}
+	return id, nil
+}
+
+func readWalletIdentity(walletPath string, label string) (*exportedIdentity, error) {
+	wallet, err := gateway.NewFileSystemWallet(walletPath)
+	if err != nil {
+		return nil, err
+	}
+	walletID, err := wallet.Get(label)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get identity %s from wallet %s", label, walletPath)
+	}
+	x509ID, ok := walletID.(*gateway.X509Identity)
+	if !ok {
+		return nil, errors.Errorf("identity %s in wallet %s is not a X.509 identity", label, walletPath)
+	}
+	return &exportedIdentity{
+		MSPID: x509ID.MspID,
+		Cert:  x509ID.Certificate(),
+		Key:   x509ID.Key(),
+	}, nil
+}
+
+func readPKCS12Identity(filePath string, password string) (*exportedIdentity, error) {
+	pfxData, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	pk, crt, caCerts, err := pkcs12.DecodeChain(pfxData, password)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode PKCS#12 bundle %s", filePath)
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	id := &exportedIdentity{
+		Cert: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: crt.Raw})),
+		Key:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})),
+	}
+	if len(caCerts) > 0 {
+		id.CACert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCerts[0].Raw}))
+	}
+	return id, nil
+}
+
+func mapSecretIdentity(secret *corev1.Secret) (*exportedIdentity, error) {
+	user, err := helpers.GetFabricIdentityUser(secret)
+	if err != nil {
+		return nil, err
+	}
+	return &exportedIdentity{
+		Cert:   user.Cert.Pem,
+		Key:    user.Key.Pem,
+		CACert: string(secret.Data[helpers.FabricIdentitySecretRootKey]),
+	}, nil
+}
+
+func readSecretIdentity(name string, ns string) (*exportedIdentity, error) {
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := clientSet.CoreV1().Secrets(ns).Get(context.Background(), name, v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting secret %s on namespace %s", name, ns)
+	}
+	return mapSecretIdentity(secret)
+}
+
+func readFabricIdentity(name string, ns string) (*exportedIdentity, error) {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return nil, err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	fabricIdentity, err := oclient.HlfV1alpha1().FabricIdentities(ns).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting identity %s on namespace %s", name, ns)
+	}
+	// the operator stores the enrolled identity in a secret with the same name as the FabricIdentity
+	secret, err := clientSet.CoreV1().Secrets(ns).Get(ctx, fabricIdentity.Name, v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting secret for identity %s on namespace %s", name, ns)
+	}
+	id, err := mapSecretIdentity(secret)
+	if err != nil {
+		return nil, err
+	}
+	id.MSPID = fabricIdentity.Spec.MSPID
+	certAuth, err := helpers.GetCertAuthByURL(
+		clientSet,
+		oclient,
+		fabricIdentity.Spec.Cahost,
+		fabricIdentity.Spec.Caport,
+	)
+	if err != nil {
+		log.Warnf("couldn't find the CA for identity %s: %v", name, err)
+		return id, nil
+	}
+	if id.CACert == "" {
+		id.CACert = certAuth.Status.CACert
+	}
+	id.TLSCACert = certAuth.Status.TLSCACert
+	return id, nil
+}
+
+func (c *exportIdentityCmd) writeOutput(data []byte) error {
+	if c.output == "" {
+		_, err := fmt.Fprint(os.Stdout, string(data))
+		return err
+	}
+	return ioutil.WriteFile(c.output, data, 0600)
+}
+
+func (c *exportIdentityCmd) writeYAML(id *exportedIdentity) error {
+	userYaml, err := yaml.Marshal(map[string]interface{}{
+		"key": map[string]interface{}{
+			"pem": id.Key,
+		},
+		"cert": map[string]interface{}{
+			"pem": id.Cert,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(userYaml)
+}
+
+func (c *exportIdentityCmd) writeMSP(id *exportedIdentity) error {
+	files := map[string]string{
+		path.Join("signcerts", "cert.pem"): id.Cert,
+		path.Join("keystore", "priv_sk"):   id.Key,
+	}
+	if id.CACert != "" {
+		files[path.Join("cacerts", "ca.pem")] = id.CACert
+		files["config.yaml"] = mspNodeOUsConfig
+	} else {
+		log.Warnf("CA certificate not found, cacerts and NodeOUs config.yaml won't be generated, use --ca-cert to provide it")
+	}
+	if id.TLSCACert != "" {
+		files[path.Join("tlscacerts", "tlsca.pem")] = id.TLSCACert
+	}
+	for filePath, contents := range files {
+		fullPath := path.Join(c.output, filePath)
+		err := os.MkdirAll(path.Dir(fullPath), 0755)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(fullPath, []byte(contents), 0600)
+		if err != nil {
+			return err
+		}
+	}
+	log.Infof("MSP directory written to %s", c.output)
+	return nil
+}
+
+func (c *exportIdentityCmd) writeWallet(id *exportedIdentity) error {
+	if id.MSPID == "" {
+		return errors.Errorf("--mspid is required to store the identity in a wallet")
+	}
+	wallet, err := gateway.NewFileSystemWallet(c.output)
+	if err != nil {
+		return err
+	}
+	err = wallet.Put(c.walletUser, gateway.NewX509Identity(id.MSPID, id.Cert, id.Key))
+	if err != nil {
+		return err
+	}
+	log.Infof("Identity %s stored in wallet %s", c.walletUser, c.output)
+	return nil
+}
+
+func (c *exportIdentityCmd) writeSecret(id *exportedIdentity) error {
+	userYaml, err := yaml.Marshal(map[string]interface{}{
+		"key": map[string]interface{}{
+			"pem": id.Key,
+		},
+		"cert": map[string]interface{}{
+			"pem": id.Cert,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	secretData := map[string][]byte{
+		helpers.FabricIdentitySecretCertKey: []byte(id.Cert),
+		helpers.FabricIdentitySecretKeyKey:  []byte(id.Key),
+		helpers.FabricIdentitySecretUserKey: userYaml,
+	}
+	if id.CACert != "" {
+		secretData[helpers.FabricIdentitySecretRootKey] = []byte(id.CACert)
+	}
+	secret := &corev1.Secret{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      c.secretName,
+			Namespace: c.secretNamespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: secretData,
+	}
+	secretYaml, err := yaml.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(secretYaml)
+}
+
+func (c *exportIdentityCmd) writePKCS12(id *exportedIdentity) error {
+	crt, err := parseCertificatePEM(id.Cert)
+	if err != nil {
+		return err
+	}
+	pk, err := parsePrivateKeyPEM(id.Key)
+	if err != nil {
+		return err
+	}
+	var caCerts []*x509.Certificate
+	if id.CACert != "" {
+		caCert, err := parseCertificatePEM(id.CACert)
+		if err != nil {
+			return err
+		}
+		caCerts = append(caCerts, caCert)
+	}
+	pfxData, err := pkcs12.Modern.Encode(pk, crt, caCerts, c.password)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(c.output, pfxData, 0600)
+	if err != nil {
+		return err
+	}
+	log.Infof("PKCS#12 bundle written to %s", c.output)
+	return nil
+}
+
+func parseCertificatePEM(certPem string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certPem))
+	if block == nil {
+		return nil, errors.New("failed to decode certificate PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parsePrivateKeyPEM(keyPem string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(keyPem))
+	if block == nil {
+		return nil, errors.New("failed to decode private key PEM")
+	}
+	if pk, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return pk, nil
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+func newIdentityExportCMD() *cobra.Command {
+	c := &exportIdentityCmd{}
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Export HLF identity to other formats",
+		Long:    identityExportDesc,
+		Example: identityExportExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.input, "input", "", "Identity to export, a path for yaml/msp/wallet/pkcs12 or <name>.<ns> for secret/fabricidentity")
+	f.StringVar(&c.inputFormat, "input-format", fabricIdentityIdentityFormat, "Format of the input (yaml/msp/wallet/secret/pkcs12/fabricidentity)")
+	f.StringVar(&c.format, "format", yamlIdentityFormat, "Output format (yaml/msp/wallet/secret/pkcs12)")
+	f.StringVar(&c.output, "output", "", "Output file or directory, stdout is used for yaml/secret if empty")
+	f.StringVar(&c.mspID, "mspid", "", "MSP ID of the identity, overrides the one found in the input")
+	f.StringVar(&c.walletUser, "wallet-user", "", "Label of the identity in the wallet")
+	f.StringVar(&c.password, "password", "", "Password of the PKCS#12 bundle")
+	f.StringVar(&c.caCert, "ca-cert", "", "Sign CA certificate file, used for the MSP cacerts and the PKCS#12 chain")
+	f.StringVar(&c.tlsCACert, "tls-ca-cert", "", "TLS CA certificate file, used for the MSP tlscacerts")
+	f.StringVar(&c.secretName, "secret-name", "", "Name of the generated secret")
+	f.StringVar(&c.secretNamespace, "secret-namespace", helpers.DefaultNamespace, "Namespace of the generated secret")
+	return cmd
+}
@@ -11,5 +11,8 @@ func NewIdentityCMD() *cobra.Command {
 	cmd.AddCommand(newIdentityCreateCMD())
 	cmd.AddCommand(newIdentityUpdateCMD())
 	cmd.AddCommand(newIdentityDeleteCMD())
+	cmd.AddCommand(newIdentityExportCMD())
+	cmd.AddCommand(newIdentityListCMD())
+	cmd.AddCommand(newIdentityDescribeCMD())
 	return cmd
 }
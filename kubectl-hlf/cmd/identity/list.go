@@ -0,0 +1,108 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type listIdentityCmd struct {
+	namespace string
+	mspID     string
+}
+
+func (c *listIdentityCmd) validate() error {
+	return nil
+}
+
+func (c *listIdentityCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	fabricIdentities, err := oclient.HlfV1alpha1().FabricIdentities(c.namespace).List(context.Background(), v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	networkConfigsByIdentity, err := getNetworkConfigsByIdentity(oclient)
+	if err != nil {
+		return err
+	}
+	data := [][]string{}
+	for _, fabricIdentity := range fabricIdentities.Items {
+		if c.mspID != "" && fabricIdentity.Spec.MSPID != c.mspID {
+			continue
+		}
+		info := getIdentityInfo(clientSet, fabricIdentity, networkConfigsByIdentity)
+		if !info.SecretFound && !info.SecretMissing {
+			log.Warnf("Couldn't get the secret of identity %s.%s: %s", fabricIdentity.Name, fabricIdentity.Namespace, info.SecretError)
+		}
+		register := ""
+		if fabricIdentity.Spec.Register != nil {
+			register = fmt.Sprintf("%s (%s)", fabricIdentity.Spec.Register.Enrollid, fabricIdentity.Spec.Register.Type)
+		}
+		var ous []string
+		if info.Certificate != nil {
+			ous = info.Certificate.OUs
+		}
+		data = append(data, []string{
+			fabricIdentity.Name,
+			fabricIdentity.Namespace,
+			fabricIdentity.Spec.MSPID,
+			fmt.Sprintf("%s:%d/%s", fabricIdentity.Spec.Cahost, fabricIdentity.Spec.Caport, fabricIdentity.Spec.Caname),
+			fabricIdentity.Spec.Enrollid,
+			register,
+			string(fabricIdentity.Status.Status),
+			info.secretStatus(),
+			strings.Join(ous, ","),
+			info.expiry(),
+			strings.Join(info.NetworkConfigs, ","),
+		})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Namespace", "MSP ID", "CA", "Enroll ID", "Register", "Status", "Secret", "OUs", "Expires", "Network Configs"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
+
+func newIdentityListCMD() *cobra.Command {
+	c := &listIdentityCmd{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List HLF identities",
+		Long:  `List HLF identities with the status of their secret and certificate`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.namespace, "namespace", "", "Namespace of the identities, all namespaces if empty")
+	f.StringVar(&c.mspID, "mspid", "", "Only list identities with this MSP ID")
+	return cmd
+}
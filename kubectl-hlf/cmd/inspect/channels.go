@@ -0,0 +1,166 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type profileChannelPeer struct {
+	EndorsingPeer  bool
+	ChaincodeQuery bool
+	LedgerQuery    bool
+	EventSource    bool
+}
+
+// channelMembers are the peers and orderers of a channel, nil members means the channel is not
+// managed by the operator and every node is added to it
+type channelMembers struct {
+	Peers    []string
+	Orderers []string
+}
+
+func trimScheme(url string) string {
+	if idx := strings.Index(url, "://"); idx != -1 {
+		return url[idx+3:]
+	}
+	return url
+}
+
+func ordererMatches(orderer *helpers.ClusterOrdererNode, url string) bool {
+	url = trimScheme(url)
+	return url == orderer.PublicURL || url == orderer.PrivateURL
+}
+
+// getChannelMembers resolves the members of the channels from the FabricMainChannel and
+// FabricFollowerChannel resources, peers come from the follower channels that joined them and
+// orderers from the orderer organizations of the main channel and the orderers the followers use
+func getChannelMembers(
+	oclient *operatorv1.Clientset,
+	channels []string,
+	peers []*helpers.ClusterPeer,
+	orderers []*helpers.ClusterOrdererNode,
+) (map[string]*channelMembers, error) {
+	ctx := context.Background()
+	mainChannels, err := oclient.HlfV1alpha1().FabricMainChannels().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	followerChannels, err := oclient.HlfV1alpha1().FabricFollowerChannels().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	members := map[string]*channelMembers{}
+	for _, channel := range channels {
+		if channel == defaultChannel {
+			continue
+		}
+		var peerNames []string
+		var ordererNames []string
+		found := false
+		addOrderer := func(name string) {
+			if !utils.Contains(ordererNames, name) {
+				ordererNames = append(ordererNames, name)
+			}
+		}
+		for _, followerChannel := range followerChannels.Items {
+			if followerChannel.Spec.Name != channel {
+				continue
+			}
+			found = true
+			for _, peerToJoin := range followerChannel.Spec.PeersToJoin {
+				name := fmt.Sprintf("%s.%s", peerToJoin.Name, peerToJoin.Namespace)
+				if !utils.Contains(peerNames, name) {
+					peerNames = append(peerNames, name)
+				}
+			}
+			for _, followerOrderer := range followerChannel.Spec.Orderers {
+				for _, orderer := range orderers {
+					if ordererMatches(orderer, followerOrderer.URL) {
+						addOrderer(orderer.Name)
+					}
+				}
+			}
+		}
+		for _, mainChannel := range mainChannels.Items {
+			if mainChannel.Spec.Name != channel {
+				continue
+			}
+			found = true
+			for _, ordererOrg := range mainChannel.Spec.OrdererOrganizations {
+				for _, ordererToJoin := range ordererOrg.OrderersToJoin {
+					addOrderer(fmt.Sprintf("%s.%s", ordererToJoin.Name, ordererToJoin.Namespace))
+				}
+				for _, endpoint := range ordererOrg.OrdererEndpoints {
+					for _, orderer := range orderers {
+						if ordererMatches(orderer, endpoint) {
+							addOrderer(orderer.Name)
+						}
+					}
+				}
+			}
+			if len(peerNames) == 0 {
+				// no follower channels, the peers of the organizations of the channel are used
+				var mspIDs []string
+				for _, peerOrg := range mainChannel.Spec.PeerOrganizations {
+					mspIDs = append(mspIDs, peerOrg.MSPID)
+				}
+				for _, peerOrg := range mainChannel.Spec.ExternalPeerOrganizations {
+					mspIDs = append(mspIDs, peerOrg.MSPID)
+				}
+				for _, peer := range peers {
+					if utils.Contains(mspIDs, peer.MSPID) {
+						peerNames = append(peerNames, peer.Name)
+					}
+				}
+			}
+		}
+		if !found {
+			log.Warnf("Channel %s not found in the cluster, all the peers and orderers will be added to it", channel)
+			continue
+		}
+		members[channel] = &channelMembers{
+			Peers:    peerNames,
+			Orderers: ordererNames,
+		}
+	}
+	return members, nil
+}
+
+// channelPeers returns the peers of the channel with their roles, peers of the client
+// organization are used for queries and events while the rest are only used to endorse
+func (p *networkProfile) channelPeers(channel string) map[string]profileChannelPeer {
+	members := p.ChannelMembers[channel]
+	channelPeers := map[string]profileChannelPeer{}
+	for _, peer := range p.Peers {
+		if members != nil && !utils.Contains(members.Peers, peer.Name) {
+			continue
+		}
+		ownOrg := members == nil || peer.MSPID == p.Organization
+		channelPeers[peer.Name] = profileChannelPeer{
+			EndorsingPeer:  true,
+			ChaincodeQuery: ownOrg,
+			LedgerQuery:    ownOrg,
+			EventSource:    ownOrg,
+		}
+	}
+	return channelPeers
+}
+
+func (p *networkProfile) channelOrderers(channel string) []string {
+	members := p.ChannelMembers[channel]
+	orderers := []string{}
+	for _, orderer := range p.Orderers {
+		if members != nil && !utils.Contains(members.Orderers, orderer.Name) {
+			continue
+		}
+		orderers = append(orderers, orderer.Name)
+	}
+	return orderers
+}
@@ -0,0 +1,284 @@
+package inspect
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/networkconfig"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Options selects the components of the cluster included in a network profile
+type Options struct {
+	// Organization is the client organization, the first organization is used when empty
+	Organization  string
+	Organizations []string
+	Namespaces    []string
+	OrdererNodes  []string
+	Channels      []string
+	Internal      bool
+	// Identities in the format MSPID:user=fabricidentity/<name>.<ns> or MSPID:user=<enroll output>
+	Identities    []string
+	ExternalNodes *helpers.ExternalNodes
+}
+
+func newProfileFromCluster(opts Options) (*networkProfile, error) {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return nil, err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	ns := ""
+	certAuths, err := helpers.GetClusterCAs(clientSet, oclient, ns)
+	if err != nil {
+		return nil, err
+	}
+	filterByOrgs := len(opts.Organizations) > 0
+	filterByNS := len(opts.Namespaces) > 0
+	filterByOrdererNodes := len(opts.OrdererNodes) > 0
+	var certAuthsFiltered []*helpers.ClusterCA
+	for _, certAuth := range certAuths {
+		if filterByNS && !utils.Contains(opts.Namespaces, certAuth.Namespace) {
+			continue
+		}
+		certAuthsFiltered = append(certAuthsFiltered, certAuth)
+	}
+	clusterOrderersNodes, err := helpers.GetClusterOrdererNodes(clientSet, oclient, "")
+	if err != nil {
+		return nil, err
+	}
+	peerOrgs, clusterPeers, err := helpers.GetClusterPeers(clientSet, oclient, ns)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Found %d organizations", len(peerOrgs))
+	orgMap := map[string]*helpers.Organization{}
+	for _, ordererNode := range clusterOrderersNodes {
+		if filterByNS && !utils.Contains(opts.Namespaces, ordererNode.Namespace) {
+			continue
+		}
+		if filterByOrdererNodes && !utils.Contains(opts.OrdererNodes, ordererNode.Name) {
+			continue
+		}
+		if (filterByOrgs && utils.Contains(opts.Organizations, ordererNode.Spec.MspID)) || !filterByOrgs {
+			org, ok := orgMap[ordererNode.Spec.MspID]
+			if ok {
+				org.OrdererNodes = append(org.OrdererNodes, ordererNode)
+			} else {
+				orgMap[ordererNode.Spec.MspID] = &helpers.Organization{
+					Type:         helpers.OrdererType,
+					MspID:        ordererNode.Spec.MspID,
+					OrdererNodes: []*helpers.ClusterOrdererNode{ordererNode},
+					Peers:        []*helpers.ClusterPeer{},
+				}
+			}
+		}
+	}
+	for _, v := range peerOrgs {
+		if !filterByOrgs {
+			orgMap[v.MspID] = v
+		} else if filterByOrgs && utils.Contains(opts.Organizations, v.MspID) {
+			orgMap[v.MspID] = v
+		}
+	}
+	var peers []*helpers.ClusterPeer
+	for _, peer := range clusterPeers {
+		if filterByNS && !utils.Contains(opts.Namespaces, peer.Namespace) {
+			continue
+		}
+		if (filterByOrgs && utils.Contains(opts.Organizations, peer.MSPID)) || !filterByOrgs {
+			peers = append(peers, peer)
+		}
+	}
+
+	var orderers []*helpers.ClusterOrdererNode
+	for _, orderer := range clusterOrderersNodes {
+		if filterByNS && !utils.Contains(opts.Namespaces, orderer.Namespace) {
+			continue
+		}
+		if filterByOrdererNodes && !utils.Contains(opts.OrdererNodes, orderer.Name) {
+			continue
+		}
+		if !filterByOrgs {
+			orderers = append(orderers, orderer)
+		} else if filterByOrgs && utils.Contains(opts.Organizations, orderer.Item.Spec.MspID) {
+			orderers = append(orderers, orderer)
+		}
+	}
+	organization := opts.Organization
+	if organization == "" && len(opts.Organizations) > 0 {
+		organization = opts.Organizations[0]
+	}
+	channelMembers, err := getChannelMembers(oclient, opts.Channels, clusterPeers, clusterOrderersNodes)
+	if err != nil {
+		return nil, err
+	}
+	profile := newNetworkProfile(orgMap, peers, orderers, certAuthsFiltered, organization, opts.Channels, channelMembers, opts.Internal)
+	if opts.ExternalNodes != nil {
+		profile.addExternalNodes(opts.ExternalNodes)
+	}
+	for _, identity := range opts.Identities {
+		id, err := parseIdentity(clientSet, oclient, identity)
+		if err != nil {
+			return nil, err
+		}
+		profile.Identities = append(profile.Identities, *id)
+	}
+	return profile, nil
+}
+
+// GenerateNetworkConfig generates the network config for the go sdk from the components
+// deployed in the cluster
+func GenerateNetworkConfig(opts Options, cryptoPath string) (*networkconfig.NetworkConfig, error) {
+	profile, err := newProfileFromCluster(opts)
+	if err != nil {
+		return nil, err
+	}
+	networkConfig, err := newGoSDKConfig(profile, cryptoPath)
+	if err != nil {
+		return nil, err
+	}
+	err = networkConfig.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return networkConfig, nil
+}
+
+// profileFromNetworkConfig builds the profile back from a go sdk network config, every user of
+// the organizations is kept as an identity
+func profileFromNetworkConfig(networkConfig *networkconfig.NetworkConfig) *networkProfile {
+	profile := &networkProfile{
+		Organization:   networkConfig.Client.Organization,
+		ChannelMembers: map[string]*channelMembers{},
+	}
+	nodeMSPIDs := map[string]string{}
+	var mspIDs []string
+	for mspID := range networkConfig.Organizations {
+		mspIDs = append(mspIDs, mspID)
+	}
+	sort.Strings(mspIDs)
+	for _, mspID := range mspIDs {
+		org := networkConfig.Organizations[mspID]
+		profile.Organizations = append(profile.Organizations, profileOrg{
+			MSPID:    org.MSPID,
+			Peers:    org.Peers,
+			Orderers: org.Orderers,
+			CAs:      org.CertificateAuthorities,
+		})
+		for _, name := range append(append([]string{}, org.Peers...), org.Orderers...) {
+			nodeMSPIDs[name] = org.MSPID
+		}
+		var userNames []string
+		for userName := range org.Users {
+			userNames = append(userNames, userName)
+		}
+		sort.Strings(userNames)
+		for _, userName := range userNames {
+			profile.Identities = append(profile.Identities, profileIdentity{
+				MSPID: org.MSPID,
+				Name:  userName,
+				User:  org.Users[userName],
+			})
+		}
+	}
+	toProfileNodes := func(nodes map[string]networkconfig.Node) []profileNode {
+		var names []string
+		for name := range nodes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var profileNodes []profileNode
+		for _, name := range names {
+			node := nodes[name]
+			hostOverride := node.GRPCOptions.SSLTargetNameOverride
+			if hostOverride == "" {
+				hostOverride = getHostOverride(node.URL)
+			}
+			profileNodes = append(profileNodes, profileNode{
+				Name:         name,
+				MSPID:        nodeMSPIDs[name],
+				URL:          node.URL,
+				HostOverride: hostOverride,
+				TLSCACert:    node.TLSCACerts.Pem,
+			})
+		}
+		return profileNodes
+	}
+	profile.Peers = toProfileNodes(networkConfig.Peers)
+	profile.Orderers = toProfileNodes(networkConfig.Orderers)
+	var caNames []string
+	for name := range networkConfig.CertificateAuthorities {
+		caNames = append(caNames, name)
+	}
+	sort.Strings(caNames)
+	for _, name := range caNames {
+		certAuth := networkConfig.CertificateAuthorities[name]
+		profileCA := profileCA{
+			Name:   name,
+			URL:    certAuth.URL,
+			CAName: certAuth.CAName,
+		}
+		if len(certAuth.TLSCACerts.Pem) > 0 {
+			profileCA.TLSCACert = certAuth.TLSCACerts.Pem[0]
+		}
+		if certAuth.Registrar != nil {
+			profileCA.EnrollID = certAuth.Registrar.EnrollID
+			profileCA.EnrollSecret = certAuth.Registrar.EnrollSecret
+		}
+		profile.CAs = append(profile.CAs, profileCA)
+	}
+	for name, channel := range networkConfig.Channels {
+		profile.Channels = append(profile.Channels, name)
+		members := &channelMembers{Orderers: channel.Orderers}
+		for peer := range channel.Peers {
+			members.Peers = append(members.Peers, peer)
+		}
+		profile.ChannelMembers[name] = members
+	}
+	sort.Strings(profile.Channels)
+	return profile
+}
+
+// ConvertNetworkConfig renders a go sdk network config in another profile format, identity selects
+// the embedded user in the format MSPID:user that becomes the default of the client
+func ConvertNetworkConfig(networkConfig *networkconfig.NetworkConfig, format string, identity string) ([]byte, error) {
+	if identity != "" {
+		chunks := strings.SplitN(identity, ":", 2)
+		if len(chunks) != 2 {
+			return nil, errors.Errorf("invalid identity %s, must be in format MSPID:user", identity)
+		}
+		org, ok := networkConfig.Organizations[chunks[0]]
+		if !ok {
+			return nil, errors.Errorf("organization %s not found in the network config", chunks[0])
+		}
+		if _, ok := org.Users[chunks[1]]; !ok {
+			return nil, errors.Errorf("user %s not found in organization %s", chunks[1], chunks[0])
+		}
+		networkConfig.Client.Organization = chunks[0]
+	}
+	switch format {
+	case yamlFormat, goSDKFormat:
+		return networkConfig.Marshal()
+	case jsonFormat:
+		return networkConfig.MarshalIndentJSON()
+	}
+	profile := profileFromNetworkConfig(networkConfig)
+	if identity != "" {
+		var identities []profileIdentity
+		for _, id := range profile.Identities {
+			if fmt.Sprintf("%s:%s", id.MSPID, id.Name) == identity {
+				identities = append(identities, id)
+			}
+		}
+		profile.Identities = identities
+	}
+	return marshalProfile(profile, format)
+}
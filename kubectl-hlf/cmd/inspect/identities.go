@@ -0,0 +1,95 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/networkconfig"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const fabricIdentityPrefix = "fabricidentity/"
+
+// profileIdentity is a user embedded in the generated profile
+type profileIdentity struct {
+	MSPID string
+	Name  string
+	User  networkconfig.User
+}
+
+// parseIdentity parses an identity in the format MSPID:user=fabricidentity/name.namespace or
+// MSPID:user=path, where path is the output of enrolling a user
+func parseIdentity(
+	clientSet *kubernetes.Clientset,
+	oclient *operatorv1.Clientset,
+	value string,
+) (*profileIdentity, error) {
+	mspIDUser := strings.SplitN(value, ":", 2)
+	if len(mspIDUser) != 2 {
+		return nil, fmt.Errorf("invalid identity %s, must be in format MSPID:user=source", value)
+	}
+	userSource := strings.SplitN(mspIDUser[1], "=", 2)
+	if len(userSource) != 2 || userSource[0] == "" || userSource[1] == "" {
+		return nil, fmt.Errorf("invalid identity %s, must be in format MSPID:user=source", value)
+	}
+	id := &profileIdentity{
+		MSPID: mspIDUser[0],
+		Name:  userSource[0],
+	}
+	source := userSource[1]
+	if strings.HasPrefix(source, fabricIdentityPrefix) {
+		chunks := strings.Split(strings.TrimPrefix(source, fabricIdentityPrefix), ".")
+		if len(chunks) != 2 {
+			return nil, fmt.Errorf("invalid fabric identity %s, must be in format fabricidentity/<name>.<ns>", source)
+		}
+		user, err := readFabricIdentityUser(clientSet, oclient, id.MSPID, chunks[0], chunks[1])
+		if err != nil {
+			return nil, err
+		}
+		id.User = *user
+	} else {
+		userBytes, err := ioutil.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		err = yaml.Unmarshal(userBytes, &id.User)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the user %s", source)
+		}
+	}
+	if id.User.Cert.Pem == "" || id.User.Key.Pem == "" {
+		return nil, errors.Errorf("identity %s has no certificate or private key", value)
+	}
+	return id, nil
+}
+
+// readFabricIdentityUser reads the credentials the operator stored for a FabricIdentity in the
+// secret with the same name
+func readFabricIdentityUser(
+	clientSet *kubernetes.Clientset,
+	oclient *operatorv1.Clientset,
+	mspID string,
+	name string,
+	ns string,
+) (*networkconfig.User, error) {
+	ctx := context.Background()
+	fabricIdentity, err := oclient.HlfV1alpha1().FabricIdentities(ns).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting identity %s on namespace %s", name, ns)
+	}
+	if fabricIdentity.Spec.MSPID != mspID {
+		return nil, errors.Errorf("identity %s on namespace %s belongs to %s, not to %s", name, ns, fabricIdentity.Spec.MSPID, mspID)
+	}
+	secret, err := clientSet.CoreV1().Secrets(ns).Get(ctx, fabricIdentity.Name, v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting secret for identity %s on namespace %s", name, ns)
+	}
+	return helpers.GetFabricIdentityUser(secret)
+}
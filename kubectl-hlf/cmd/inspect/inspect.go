@@ -1,40 +1,48 @@
 package inspect
 
 import (
-	"bytes"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"io/ioutil"
-	"sigs.k8s.io/yaml"
-	"text/template"
 
-	"github.com/Masterminds/sprig/v3"
-	"github.com/kfsoftware/hlf-operator/controllers/utils"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
 	"github.com/spf13/cobra"
 )
 
 const (
 	createDesc = `
-'inspect' command creates creates a configuration file ready to use for the go sdk`
-	createExample = `  kubectl hlf inspect --output hlf-cfg.yaml`
-	yamlFormat    = "yaml"
-	jsonFormat    = "json"
+'inspect' command creates creates a configuration file ready to use for the go sdk, the node sdk, the java sdk or the fabric gateway`
+	createExample = `  kubectl hlf inspect --output hlf-cfg.yaml
+  kubectl hlf inspect --format node-sdk --organizations Org1MSP --channels mychannel --output connection-org1.json
+  kubectl hlf inspect --organizations Org1MSP --channels ch1,ch2 --output hlf-cfg.yaml
+  kubectl hlf inspect --organizations Org1MSP --external-peer peer0-partner=grpcs://peer0.partner.com:443,tlsca=partner-tlsca.pem,mspid=PartnerMSP
+  kubectl hlf inspect --organizations Org1MSP --identity Org1MSP:admin=fabricidentity/org1-admin.default --identity Org1MSP:app=./app.yaml`
+	yamlFormat = "yaml"
+	jsonFormat = "json"
 )
 
 type inspectCmd struct {
-	fileOutput    string
-	organizations []string
-	internal      bool
-	format        string
-	namespaces    []string
-	ordererNodes  []string
-	channels      []string
+	fileOutput       string
+	organizations    []string
+	internal         bool
+	format           string
+	namespaces       []string
+	ordererNodes     []string
+	channels         []string
+	cryptoPath       string
+	externalPeers    []string
+	externalOrderers []string
+	externalNodes    string
+	identities       []string
 }
 
 func (c *inspectCmd) validate() error {
-	return nil
+	switch c.format {
+	case yamlFormat, jsonFormat, goSDKFormat, nodeSDKFormat, javaSDKFormat, fabricGatewayFormat, ccpJSONFormat:
+		return nil
+	}
+	return fmt.Errorf("invalid format %s, must be one of go-sdk, node-sdk, java-sdk, fabric-gateway, ccp-json, yaml or json", c.format)
 }
 
 type OrderingService struct {
@@ -50,248 +58,50 @@ type Peer struct {
 	TLSCert string
 }
 
-const tmplGoConfig = `
-name: hlf-network
-version: 1.0.0
-client:
-  organization: "{{ .Organization }}"
-{{- if not .Organizations }}
-organizations: {}
-{{- else }}
-organizations:
-  {{ range $mspID, $org := .Organizations }}
-  {{$mspID}}:
-    mspid: {{$mspID}}
-    cryptoPath: /tmp/cryptopath
-    users: {}
-{{- if not $org.Peers }}
-    peers: []
-{{- else }}
-    peers:
-      {{- range $peer := $org.Peers }}
-      - {{ $peer.Name }}
- 	  {{- end }}
-{{- end }}
-{{- if not $org.OrdererNodes }}
-    orderers: []
-{{- else }}
-    orderers:
-      {{- range $orderer := $org.OrdererNodes }}
-      - {{ $orderer.Name }}
- 	  {{- end }}
-
-    {{- end }}
-{{- end }}
-{{- end }}
-
-{{- if not .Orderers }}
-orderers: []
-{{- else }}
-orderers:
-{{- range $orderer := .Orderers }}
-  {{$orderer.Name}}:
-{{if $.Internal }}
-    url: grpcs://{{ $orderer.PrivateURL }}
-{{ else }}
-    url: grpcs://{{ $orderer.PublicURL }}
-{{ end }}
-    grpcOptions:
-      allow-insecure: false
-    tlsCACerts:
-      pem: |
-{{ or $orderer.Status.TlsCACert $orderer.Status.TlsCert | indent 8 }}
-{{- end }}
-{{- end }}
-
-{{- if not .Peers }}
-peers: {}
-{{- else }}
-peers:
-  {{- range $peer := .Peers }}
-  {{$peer.Name}}:
-{{if $.Internal }}
-    url: grpcs://{{ $peer.PrivateURL }}
-{{ else }}
-    url: grpcs://{{ $peer.PublicURL }}
-{{ end }}
-    grpcOptions:
-      allow-insecure: false
-    tlsCACerts:
-      pem: |
-{{ $peer.Status.TlsCACert | indent 8 }}
-{{- end }}
-{{- end }}
-
-{{- if not .CertAuths }}
-certificateAuthorities: []
-{{- else }}
-certificateAuthorities:
-{{- range $ca := .CertAuths }}
-  
-  {{ $ca.Name }}:
-{{if $.Internal }}
-    url: https://{{ $ca.PrivateURL }}
-{{ else }}
-    url: https://{{ $ca.PublicURL }}
-{{ end }}
-{{if $ca.EnrollID }}
-    registrar:
-        enrollId: {{ $ca.EnrollID }}
-        enrollSecret: "{{ $ca.EnrollPWD }}"
-{{ end }}
-    caName: ca
-    tlsCACerts:
-      pem: 
-       - |
-{{ $ca.Status.TlsCert | indent 12 }}
-
-{{- end }}
-{{- end }}
-
-channels:
-{{- range $channel := .Channels }}
-  {{ $channel }}:
-{{- if not $.Orderers }}
-    orderers: []
-{{- else }}
-    orderers:
-{{- range $orderer := $.Orderers }}
-      - {{$orderer.Name}}
-{{- end }}
-{{- end }}
-{{- if not $.Peers }}
-    peers: {}
-{{- else }}
-    peers:
-{{- range $peer := $.Peers }}
-       {{$peer.Name}}:
-        discover: true
-        endorsingPeer: true
-        chaincodeQuery: true
-        ledgerQuery: true
-        eventSource: true
-{{- end }}
-{{- end }}
-{{- end }}
-
-`
-
 func (c *inspectCmd) run(out io.Writer) error {
-	oclient, err := helpers.GetKubeOperatorClient()
-	if err != nil {
-		return err
-	}
-	clientSet, err := helpers.GetKubeClient()
+	externalNodes, err := helpers.GetExternalNodes(c.externalNodes, c.externalPeers, c.externalOrderers)
 	if err != nil {
 		return err
 	}
-	ns := ""
-	certAuths, err := helpers.GetClusterCAs(clientSet, oclient, ns)
-	if err != nil {
-		return err
-	}
-	filterByOrgs := len(c.organizations) > 0
-	filterByNS := len(c.namespaces) > 0
-	filterByOrdererNodes := len(c.ordererNodes) > 0
-	var certAuthsFiltered []*helpers.ClusterCA
-	for _, certAuth := range certAuths {
-		if filterByNS && !utils.Contains(c.namespaces, certAuth.Namespace) {
-			continue
-		}
-		certAuthsFiltered = append(certAuthsFiltered, certAuth)
-	}
-	clusterOrderersNodes, err := helpers.GetClusterOrdererNodes(clientSet, oclient, "")
-	if err != nil {
-		return err
-	}
-	peerOrgs, clusterPeers, err := helpers.GetClusterPeers(clientSet, oclient, ns)
+	profile, err := newProfileFromCluster(Options{
+		Organizations: c.organizations,
+		Namespaces:    c.namespaces,
+		OrdererNodes:  c.ordererNodes,
+		Channels:      c.channels,
+		Internal:      c.internal,
+		Identities:    c.identities,
+		ExternalNodes: externalNodes,
+	})
 	if err != nil {
 		return err
 	}
-	log.Infof("Found %d organizations", len(peerOrgs))
-	orgMap := map[string]*helpers.Organization{}
-	for _, ordererNode := range clusterOrderersNodes {
-		if filterByNS && !utils.Contains(c.namespaces, ordererNode.Namespace) {
-			continue
-		}
-		if filterByOrdererNodes && !utils.Contains(c.ordererNodes, ordererNode.Name) {
-			continue
-		}
-		if (filterByOrgs && utils.Contains(c.organizations, ordererNode.Spec.MspID)) || !filterByOrgs {
-			org, ok := orgMap[ordererNode.Spec.MspID]
-			if ok {
-				org.OrdererNodes = append(org.OrdererNodes, ordererNode)
-			} else {
-				orgMap[ordererNode.Spec.MspID] = &helpers.Organization{
-					Type:         helpers.OrdererType,
-					MspID:        ordererNode.Spec.MspID,
-					OrdererNodes: []*helpers.ClusterOrdererNode{ordererNode},
-					Peers:        []*helpers.ClusterPeer{},
-				}
-			}
-		}
-	}
-	for _, v := range peerOrgs {
-		if !filterByOrgs {
-			orgMap[v.MspID] = v
-		} else if filterByOrgs && utils.Contains(c.organizations, v.MspID) {
-			orgMap[v.MspID] = v
-		}
-	}
-	var peers []*helpers.ClusterPeer
-	for _, peer := range clusterPeers {
-		if filterByNS && !utils.Contains(c.namespaces, peer.Namespace) {
-			continue
+	var data []byte
+	switch c.format {
+	case yamlFormat, jsonFormat, goSDKFormat:
+		networkConfig, err := newGoSDKConfig(profile, c.cryptoPath)
+		if err != nil {
+			return err
 		}
-		if (filterByOrgs && utils.Contains(c.organizations, peer.MSPID)) || !filterByOrgs {
-			peers = append(peers, peer)
+		err = networkConfig.Validate()
+		if err != nil {
+			return err
 		}
-	}
-
-	var orderers []*helpers.ClusterOrdererNode
-	for _, orderer := range clusterOrderersNodes {
-		if filterByNS && !utils.Contains(c.namespaces, orderer.Namespace) {
-			continue
+		if c.format == jsonFormat {
+			data, err = networkConfig.MarshalIndentJSON()
+		} else {
+			data, err = networkConfig.Marshal()
 		}
-		if filterByOrdererNodes && !utils.Contains(c.ordererNodes, orderer.Name) {
-			continue
+		if err != nil {
+			return err
 		}
-		if !filterByOrgs {
-			orderers = append(orderers, orderer)
-		} else if filterByOrgs && utils.Contains(c.organizations, orderer.Item.Spec.MspID) {
-			orderers = append(orderers, orderer)
+	default:
+		if len(profile.Identities) > 0 && c.format != fabricGatewayFormat {
+			log.Warnf("Identities are not embedded in %s profiles", c.format)
 		}
-	}
-	tmpl, err := template.New("test").Funcs(sprig.HermeticTxtFuncMap()).Parse(tmplGoConfig)
-	if err != nil {
-		return err
-	}
-	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, map[string]interface{}{
-		"Peers":         peers,
-		"Orderers":      orderers,
-		"Organizations": orgMap,
-		"CertAuths":     certAuthsFiltered,
-		"Internal":      c.internal,
-		"Channels":      c.channels,
-	})
-	if err != nil {
-		return err
-	}
-
-	var data []byte
-	if c.format != yamlFormat && c.format != jsonFormat {
-		fmt.Fprint(out, "Invalid output format... Default to yaml")
-		c.format = yamlFormat
-	}
-
-	if c.format == jsonFormat {
-		data, err = yaml.YAMLToJSON(buf.Bytes())
+		data, err = marshalProfile(profile, c.format)
 		if err != nil {
 			return err
 		}
-	} else {
-		data = buf.Bytes()
 	}
 
 	if c.fileOutput != "" {
@@ -329,9 +139,14 @@ func NewInspectHLFConfig(out io.Writer) *cobra.Command {
 	f.BoolVar(&c.internal, "internal", false, "Use kubernetes service names")
 	f.StringArrayVarP(&c.organizations, "organizations", "o", []string{}, "Organizations to export")
 	f.StringArrayVarP(&c.ordererNodes, "ordererNodes", "", []string{}, "Orderer nodes to export")
-	f.StringVar(&c.format, "format", yamlFormat, "Connection profile output format (yaml/json)")
+	f.StringVar(&c.format, "format", yamlFormat, "Connection profile format: go-sdk, node-sdk, java-sdk, fabric-gateway or ccp-json, yaml and json are go-sdk profiles")
+	f.StringVar(&c.cryptoPath, "crypto-path", "/tmp/cryptopath", "Crypto path of the organizations for the go sdk")
 	f.StringArrayVarP(&c.namespaces, "namespace", "n", []string{}, "Namespace scope for this request")
-	f.StringArrayVarP(&c.channels, "channels", "c", []string{"_default"}, "Channels for the network config")
+	f.StringArrayVar(&c.externalPeers, "external-peer", []string{}, "Peer outside of the cluster in the format name=grpcs://host:port,tlsca=file,mspid=MSPID[,channels=ch1;ch2]")
+	f.StringArrayVar(&c.externalOrderers, "external-orderer", []string{}, "Orderer outside of the cluster in the format name=grpcs://host:port,tlsca=file,mspid=MSPID[,channels=ch1;ch2]")
+	f.StringVar(&c.externalNodes, "external-nodes", "", "YAML file with the peers and orderers outside of the cluster")
+	f.StringArrayVar(&c.identities, "identity", []string{}, "Identity to embed in the format MSPID:user=fabricidentity/<name>.<ns> or MSPID:user=<enroll output>")
+	f.StringSliceVarP(&c.channels, "channels", "c", []string{"_default"}, "Channels for the network config, the members of each channel are read from the channel resources")
 
 	return cmd
 }
@@ -0,0 +1,519 @@
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/networkconfig"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	goSDKFormat         = "go-sdk"
+	nodeSDKFormat       = "node-sdk"
+	javaSDKFormat       = "java-sdk"
+	fabricGatewayFormat = "fabric-gateway"
+	ccpJSONFormat       = "ccp-json"
+
+	defaultChannel = "_default"
+)
+
+type profileNode struct {
+	Name         string
+	MSPID        string
+	URL          string
+	HostOverride string
+	TLSCACert    string
+}
+
+type profileCA struct {
+	Name         string
+	URL          string
+	CAName       string
+	TLSCACert    string
+	EnrollID     string
+	EnrollSecret string
+}
+
+type profileOrg struct {
+	MSPID    string
+	Peers    []string
+	Orderers []string
+	CAs      []string
+}
+
+// networkProfile is the SDK independent view of the network used by the profile generators
+type networkProfile struct {
+	Organization  string
+	Organizations []profileOrg
+	Peers         []profileNode
+	Orderers      []profileNode
+	CAs           []profileCA
+	Channels      []string
+	// ChannelMembers are the members of the channels found in the cluster
+	ChannelMembers map[string]*channelMembers
+	Identities     []profileIdentity
+}
+
+func getHostOverride(url string) string {
+	hostPort := url[strings.Index(url, "://")+3:]
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return hostPort
+	}
+	return host
+}
+
+// findOrgCA finds the CA the peers of the organization were enrolled with
+func findOrgCA(certAuths []*helpers.ClusterCA, peer *helpers.ClusterPeer) *helpers.ClusterCA {
+	for _, certAuth := range certAuths {
+		if helpers.CAHostMatches(certAuth, peer.Spec.Secret.Enrollment.Component.Cahost) {
+			return certAuth
+		}
+	}
+	return nil
+}
+
+func newNetworkProfile(
+	orgMap map[string]*helpers.Organization,
+	peers []*helpers.ClusterPeer,
+	orderers []*helpers.ClusterOrdererNode,
+	certAuths []*helpers.ClusterCA,
+	organization string,
+	channels []string,
+	channelMembers map[string]*channelMembers,
+	internal bool,
+) *networkProfile {
+	profile := &networkProfile{
+		Organization:   organization,
+		Channels:       channels,
+		ChannelMembers: channelMembers,
+	}
+	for _, peer := range peers {
+		url := fmt.Sprintf("grpcs://%s", peer.PublicURL)
+		if internal {
+			url = fmt.Sprintf("grpcs://%s", peer.PrivateURL)
+		}
+		profile.Peers = append(profile.Peers, profileNode{
+			Name:         peer.Name,
+			MSPID:        peer.MSPID,
+			URL:          url,
+			HostOverride: getHostOverride(url),
+			TLSCACert:    peer.Status.TlsCACert,
+		})
+	}
+	for _, orderer := range orderers {
+		url := fmt.Sprintf("grpcs://%s", orderer.PublicURL)
+		if internal {
+			url = fmt.Sprintf("grpcs://%s", orderer.PrivateURL)
+		}
+		tlsCACert := orderer.Status.TlsCACert
+		if tlsCACert == "" {
+			tlsCACert = orderer.Status.TlsCert
+		}
+		profile.Orderers = append(profile.Orderers, profileNode{
+			Name:         orderer.Name,
+			MSPID:        orderer.Spec.MspID,
+			URL:          url,
+			HostOverride: getHostOverride(url),
+			TLSCACert:    tlsCACert,
+		})
+	}
+	for _, certAuth := range certAuths {
+		url := fmt.Sprintf("https://%s", certAuth.PublicURL)
+		if internal {
+			url = fmt.Sprintf("https://%s", certAuth.PrivateURL)
+		}
+		caName := certAuth.Spec.CA.Name
+		if caName == "" {
+			caName = "ca"
+		}
+		profile.CAs = append(profile.CAs, profileCA{
+			Name:         certAuth.Name,
+			URL:          url,
+			CAName:       caName,
+			TLSCACert:    certAuth.Status.TlsCert,
+			EnrollID:     certAuth.EnrollID,
+			EnrollSecret: certAuth.EnrollPWD,
+		})
+	}
+	var mspIDs []string
+	for mspID := range orgMap {
+		mspIDs = append(mspIDs, mspID)
+	}
+	sort.Strings(mspIDs)
+	for _, mspID := range mspIDs {
+		org := orgMap[mspID]
+		profileOrg := profileOrg{MSPID: mspID}
+		for _, peer := range org.Peers {
+			profileOrg.Peers = append(profileOrg.Peers, peer.Name)
+		}
+		for _, orderer := range org.OrdererNodes {
+			profileOrg.Orderers = append(profileOrg.Orderers, orderer.Name)
+		}
+		if len(org.Peers) > 0 {
+			if certAuth := findOrgCA(certAuths, org.Peers[0]); certAuth != nil {
+				profileOrg.CAs = append(profileOrg.CAs, certAuth.Name)
+			}
+		}
+		profile.Organizations = append(profile.Organizations, profileOrg)
+	}
+	if profile.Organization == "" && len(profile.Organizations) > 0 {
+		profile.Organization = profile.Organizations[0].MSPID
+	}
+	return profile
+}
+
+// addExternalNodes adds the nodes running outside of the cluster to the profile, channels whose members
+// were resolved from the cluster only get the external nodes that joined them
+func (p *networkProfile) addExternalNodes(externalNodes *helpers.ExternalNodes) {
+	orgIndex := func(mspID string) int {
+		for idx, org := range p.Organizations {
+			if org.MSPID == mspID {
+				return idx
+			}
+		}
+		p.Organizations = append(p.Organizations, profileOrg{MSPID: mspID})
+		return len(p.Organizations) - 1
+	}
+	for _, peer := range externalNodes.Peers {
+		p.Peers = append(p.Peers, profileNode{
+			Name:         peer.Name,
+			MSPID:        peer.MSPID,
+			URL:          peer.URL,
+			HostOverride: getHostOverride(peer.URL),
+			TLSCACert:    peer.TLSCACert,
+		})
+		idx := orgIndex(peer.MSPID)
+		p.Organizations[idx].Peers = append(p.Organizations[idx].Peers, peer.Name)
+		for channel, members := range p.ChannelMembers {
+			if members != nil && utils.Contains(peer.Channels, channel) {
+				members.Peers = append(members.Peers, peer.Name)
+			}
+		}
+	}
+	for _, orderer := range externalNodes.Orderers {
+		p.Orderers = append(p.Orderers, profileNode{
+			Name:         orderer.Name,
+			MSPID:        orderer.MSPID,
+			URL:          orderer.URL,
+			HostOverride: getHostOverride(orderer.URL),
+			TLSCACert:    orderer.TLSCACert,
+		})
+		idx := orgIndex(orderer.MSPID)
+		p.Organizations[idx].Orderers = append(p.Organizations[idx].Orderers, orderer.Name)
+		for channel, members := range p.ChannelMembers {
+			if members != nil && utils.Contains(orderer.Channels, channel) {
+				members.Orderers = append(members.Orderers, orderer.Name)
+			}
+		}
+	}
+}
+
+// newGoSDKConfig builds the network config for the fabric-sdk-go
+func newGoSDKConfig(profile *networkProfile, cryptoPath string) (*networkconfig.NetworkConfig, error) {
+	networkConfig := networkconfig.New("hlf-network")
+	networkConfig.Client.Organization = profile.Organization
+	for _, org := range profile.Organizations {
+		peers := org.Peers
+		if peers == nil {
+			peers = []string{}
+		}
+		orderers := org.Orderers
+		if orderers == nil {
+			orderers = []string{}
+		}
+		networkConfig.Organizations[org.MSPID] = networkconfig.Organization{
+			MSPID:                  org.MSPID,
+			CryptoPath:             cryptoPath,
+			Users:                  map[string]networkconfig.User{},
+			Peers:                  peers,
+			Orderers:               orderers,
+			CertificateAuthorities: org.CAs,
+		}
+	}
+	for _, peer := range profile.Peers {
+		networkConfig.Peers[peer.Name] = networkconfig.Node{
+			URL:        peer.URL,
+			TLSCACerts: networkconfig.Pem{Pem: peer.TLSCACert},
+		}
+	}
+	for _, orderer := range profile.Orderers {
+		networkConfig.Orderers[orderer.Name] = networkconfig.Node{
+			URL:        orderer.URL,
+			TLSCACerts: networkconfig.Pem{Pem: orderer.TLSCACert},
+		}
+	}
+	for _, certAuth := range profile.CAs {
+		ca := networkconfig.CertificateAuthority{
+			URL:        certAuth.URL,
+			CAName:     certAuth.CAName,
+			TLSCACerts: networkconfig.PemList{Pem: []string{certAuth.TLSCACert}},
+		}
+		if certAuth.EnrollID != "" {
+			ca.Registrar = &networkconfig.Registrar{
+				EnrollID:     certAuth.EnrollID,
+				EnrollSecret: certAuth.EnrollSecret,
+			}
+		}
+		networkConfig.CertificateAuthorities[certAuth.Name] = ca
+	}
+	for _, channel := range profile.Channels {
+		networkChannel := networkconfig.Channel{
+			Orderers: profile.channelOrderers(channel),
+			Peers:    map[string]networkconfig.ChannelPeer{},
+		}
+		for name, roles := range profile.channelPeers(channel) {
+			roles := roles
+			discover := true
+			networkChannel.Peers[name] = networkconfig.ChannelPeer{
+				Discover:       &discover,
+				EndorsingPeer:  &roles.EndorsingPeer,
+				ChaincodeQuery: &roles.ChaincodeQuery,
+				LedgerQuery:    &roles.LedgerQuery,
+				EventSource:    &roles.EventSource,
+			}
+		}
+		networkConfig.Channels[channel] = networkChannel
+	}
+	for _, id := range profile.Identities {
+		err := networkConfig.AddUser(id.MSPID, id.Name, id.User)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to add identity %s", id.Name)
+		}
+	}
+	return networkConfig, nil
+}
+
+type ccpProfile struct {
+	Name                   string                     `json:"name"`
+	Version                string                     `json:"version"`
+	Client                 ccpClient                  `json:"client"`
+	Channels               map[string]ccpChannel      `json:"channels,omitempty"`
+	Organizations          map[string]ccpOrganization `json:"organizations"`
+	Orderers               map[string]ccpNode         `json:"orderers,omitempty"`
+	Peers                  map[string]ccpNode         `json:"peers"`
+	CertificateAuthorities map[string]ccpCA           `json:"certificateAuthorities,omitempty"`
+}
+
+type ccpClient struct {
+	Organization string        `json:"organization"`
+	Connection   ccpConnection `json:"connection"`
+}
+
+type ccpConnection struct {
+	Timeout ccpTimeout `json:"timeout"`
+}
+
+type ccpTimeout struct {
+	Peer    map[string]string `json:"peer"`
+	Orderer string            `json:"orderer"`
+}
+
+type ccpChannel struct {
+	Orderers []string                  `json:"orderers"`
+	Peers    map[string]ccpChannelPeer `json:"peers"`
+}
+
+type ccpChannelPeer struct {
+	EndorsingPeer  bool `json:"endorsingPeer"`
+	ChaincodeQuery bool `json:"chaincodeQuery"`
+	LedgerQuery    bool `json:"ledgerQuery"`
+	EventSource    bool `json:"eventSource"`
+}
+
+type ccpOrganization struct {
+	MSPID                  string   `json:"mspid"`
+	Peers                  []string `json:"peers"`
+	Orderers               []string `json:"orderers,omitempty"`
+	CertificateAuthorities []string `json:"certificateAuthorities,omitempty"`
+}
+
+type ccpNode struct {
+	URL         string                 `json:"url"`
+	TLSCACerts  ccpPem                 `json:"tlsCACerts"`
+	GRPCOptions map[string]interface{} `json:"grpcOptions"`
+}
+
+type ccpPem struct {
+	Pem string `json:"pem"`
+}
+
+type ccpCA struct {
+	URL         string          `json:"url"`
+	CAName      string          `json:"caName"`
+	TLSCACerts  ccpPemList      `json:"tlsCACerts"`
+	HTTPOptions map[string]bool `json:"httpOptions"`
+}
+
+type ccpPemList struct {
+	Pem []string `json:"pem"`
+}
+
+// newCCPProfile builds the common connection profile used by the Node and Java SDKs, the Java SDK
+// reads the TLS host override from hostnameOverride instead of ssl-target-name-override
+func newCCPProfile(profile *networkProfile, withChannels bool, javaSDK bool) *ccpProfile {
+	ccp := &ccpProfile{
+		Name:    "hlf-network",
+		Version: "1.0.0",
+		Client: ccpClient{
+			Organization: profile.Organization,
+			Connection: ccpConnection{
+				Timeout: ccpTimeout{
+					Peer:    map[string]string{"endorser": "300"},
+					Orderer: "300",
+				},
+			},
+		},
+		Organizations:          map[string]ccpOrganization{},
+		Orderers:               map[string]ccpNode{},
+		Peers:                  map[string]ccpNode{},
+		CertificateAuthorities: map[string]ccpCA{},
+	}
+	grpcOptions := func(node profileNode) map[string]interface{} {
+		options := map[string]interface{}{
+			"ssl-target-name-override": node.HostOverride,
+		}
+		if javaSDK {
+			options["hostnameOverride"] = node.HostOverride
+		}
+		return options
+	}
+	for _, org := range profile.Organizations {
+		peers := org.Peers
+		if peers == nil {
+			peers = []string{}
+		}
+		ccp.Organizations[org.MSPID] = ccpOrganization{
+			MSPID:                  org.MSPID,
+			Peers:                  peers,
+			Orderers:               org.Orderers,
+			CertificateAuthorities: org.CAs,
+		}
+	}
+	for _, peer := range profile.Peers {
+		ccp.Peers[peer.Name] = ccpNode{
+			URL:         peer.URL,
+			TLSCACerts:  ccpPem{Pem: peer.TLSCACert},
+			GRPCOptions: grpcOptions(peer),
+		}
+	}
+	for _, orderer := range profile.Orderers {
+		ccp.Orderers[orderer.Name] = ccpNode{
+			URL:         orderer.URL,
+			TLSCACerts:  ccpPem{Pem: orderer.TLSCACert},
+			GRPCOptions: grpcOptions(orderer),
+		}
+	}
+	for _, certAuth := range profile.CAs {
+		ccp.CertificateAuthorities[certAuth.Name] = ccpCA{
+			URL:         certAuth.URL,
+			CAName:      certAuth.CAName,
+			TLSCACerts:  ccpPemList{Pem: []string{certAuth.TLSCACert}},
+			HTTPOptions: map[string]bool{"verify": true},
+		}
+	}
+	if withChannels {
+		ccp.Channels = map[string]ccpChannel{}
+		for _, channel := range profile.Channels {
+			if channel == defaultChannel {
+				continue
+			}
+			ccpChan := ccpChannel{
+				Orderers: profile.channelOrderers(channel),
+				Peers:    map[string]ccpChannelPeer{},
+			}
+			for name, roles := range profile.channelPeers(channel) {
+				ccpChan.Peers[name] = ccpChannelPeer{
+					EndorsingPeer:  roles.EndorsingPeer,
+					ChaincodeQuery: roles.ChaincodeQuery,
+					LedgerQuery:    roles.LedgerQuery,
+					EventSource:    roles.EventSource,
+				}
+			}
+			ccp.Channels[channel] = ccpChan
+		}
+	}
+	return ccp
+}
+
+type gatewayProfile struct {
+	MSPID    string           `json:"mspId"`
+	Peers    []gatewayPeer    `json:"peers"`
+	Identity *gatewayIdentity `json:"identity,omitempty"`
+}
+
+type gatewayPeer struct {
+	Name         string `json:"name"`
+	Endpoint     string `json:"endpoint"`
+	HostOverride string `json:"hostOverride"`
+	TLSRootCert  string `json:"tlsRootCert"`
+}
+
+type gatewayIdentity struct {
+	Certificate string `json:"certificate"`
+	PrivateKey  string `json:"privateKey"`
+}
+
+// newGatewayProfile builds the minimal bundle needed by the Fabric Gateway client API, which only
+// connects to the peers of the client organization
+func newGatewayProfile(profile *networkProfile) (*gatewayProfile, error) {
+	if profile.Organization == "" {
+		return nil, errors.Errorf("no organization found for the fabric gateway profile")
+	}
+	gateway := &gatewayProfile{
+		MSPID: profile.Organization,
+		Peers: []gatewayPeer{},
+	}
+	for _, peer := range profile.Peers {
+		if peer.MSPID != profile.Organization {
+			continue
+		}
+		gateway.Peers = append(gateway.Peers, gatewayPeer{
+			Name:         peer.Name,
+			Endpoint:     peer.URL[strings.Index(peer.URL, "://")+3:],
+			HostOverride: peer.HostOverride,
+			TLSRootCert:  peer.TLSCACert,
+		})
+	}
+	if len(gateway.Peers) == 0 {
+		return nil, errors.Errorf("no peers found for organization %s", profile.Organization)
+	}
+	for _, id := range profile.Identities {
+		if id.MSPID != profile.Organization {
+			continue
+		}
+		gateway.Identity = &gatewayIdentity{
+			Certificate: id.User.Cert.Pem,
+			PrivateKey:  id.User.Key.Pem,
+		}
+		break
+	}
+	return gateway, nil
+}
+
+// marshalProfile renders the profile in the requested format, the Node SDK and the generic
+// connection profile are JSON while the Java SDK profile is YAML
+func marshalProfile(profile *networkProfile, format string) ([]byte, error) {
+	switch format {
+	case nodeSDKFormat:
+		return json.MarshalIndent(newCCPProfile(profile, true, false), "", "  ")
+	case ccpJSONFormat:
+		return json.MarshalIndent(newCCPProfile(profile, false, false), "", "  ")
+	case javaSDKFormat:
+		return yaml.Marshal(newCCPProfile(profile, true, true))
+	case fabricGatewayFormat:
+		gateway, err := newGatewayProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(gateway, "", "  ")
+	}
+	return nil, errors.Errorf("unsupported format %s", format)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/ordnode"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/org"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/peer"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/upgrade"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -54,6 +55,7 @@ func NewCmdHLF() *cobra.Command {
 		operatorapi.NewOperatorAPICMD(cmd.OutOrStdout(), cmd.ErrOrStderr()),
 		operatorui.NewOperatorUICMD(cmd.OutOrStdout(), cmd.ErrOrStderr()),
 		channelcrd.NewChannelCRDCmd(cmd.OutOrStdout(), cmd.ErrOrStderr()),
+		upgrade.NewUpgradeCmd(cmd.OutOrStdout(), cmd.ErrOrStderr()),
 	)
 	return cmd
 }
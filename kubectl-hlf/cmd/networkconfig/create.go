@@ -22,6 +22,10 @@ type CreateOptions struct {
 	Internal   bool
 	SecretName string
 	Channels   []string
+
+	ExternalPeers    []string
+	ExternalOrderers []string
+	ExternalNodes    string
 }
 
 func (o CreateOptions) Validate() error {
@@ -69,6 +73,10 @@ func (c *createCmd) run(args []string) error {
 		})
 
 	}
+	externalNodes, err := helpers.GetExternalNodes(c.opts.ExternalNodes, c.opts.ExternalPeers, c.opts.ExternalOrderers)
+	if err != nil {
+		return err
+	}
 	namespaces := []string{}
 	networkConfig := &hlfv1alpha1.FabricNetworkConfig{
 		TypeMeta: v1.TypeMeta{
@@ -80,17 +88,19 @@ func (c *createCmd) run(args []string) error {
 			Namespace: c.opts.NS,
 		},
 		Spec: hlfv1alpha1.FabricNetworkConfigSpec{
-			Organization:     "",
-			Internal:         c.opts.Internal,
-			Organizations:    c.opts.Orgs,
-			Namespaces:       namespaces,
-			Channels:         c.opts.Channels,
-			Identities:       identities,
-			ExternalOrderers: []hlfv1alpha1.FabricNetworkConfigExternalOrderer{},
-			ExternalPeers:    []hlfv1alpha1.FabricNetworkConfigExternalPeer{},
-			SecretName:       secretName,
+			Organization:  "",
+			Internal:      c.opts.Internal,
+			Organizations: c.opts.Orgs,
+			Namespaces:    namespaces,
+			Channels:      c.opts.Channels,
+			Identities:    identities,
+			SecretName:    secretName,
 		},
 	}
+	err = helpers.SetNetworkConfigExternalNodes(networkConfig, externalNodes)
+	if err != nil {
+		return err
+	}
 	_, err = oclient.HlfV1alpha1().FabricNetworkConfigs(c.opts.NS).Create(
 		ctx,
 		networkConfig,
@@ -125,6 +135,9 @@ func newCreateNetworkConfigCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	f.BoolVarP(&c.opts.Internal, "internal", "i", false, "Use internal or external endpoints")
 	f.StringVarP(&c.opts.OutputPath, "output-path", "", "", "Output path")
 	f.StringSliceVarP(&c.opts.Identities, "identities", "", []string{}, "Identities to add to the network config")
+	f.StringArrayVar(&c.opts.ExternalPeers, "external-peer", []string{}, "Peer outside of the cluster in the format name=grpcs://host:port,tlsca=file,mspid=MSPID[,channels=ch1;ch2]")
+	f.StringArrayVar(&c.opts.ExternalOrderers, "external-orderer", []string{}, "Orderer outside of the cluster in the format name=grpcs://host:port,tlsca=file,mspid=MSPID[,channels=ch1;ch2]")
+	f.StringVar(&c.opts.ExternalNodes, "external-nodes", "", "YAML file with the peers and orderers outside of the cluster")
 
 	return cmd
 }
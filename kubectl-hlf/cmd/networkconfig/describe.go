@@ -0,0 +1,119 @@
+package networkconfig
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type networkConfigDescribeCmd struct {
+	out  io.Writer
+	name string
+	ns   string
+}
+
+func newDescribeNetworkConfigCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &networkConfigDescribeCmd{out: out}
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Describe a Network Config CRD",
+		Long:  `Describe a Network Config with its spec and the contents of the generated secret`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.name, "name", "", "Name of the Network Config to describe")
+	f.StringVarP(&c.ns, "namespace", "n", helpers.DefaultNamespace, "Namespace scope for this request")
+	return cmd
+}
+
+func (c *networkConfigDescribeCmd) validate() error {
+	if c.name == "" {
+		return errors.New("--name flag is required")
+	}
+	return nil
+}
+
+func printList(out io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		fmt.Fprintf(out, "%s:\t<none>\n", title)
+		return
+	}
+	fmt.Fprintf(out, "%s:\n", title)
+	for _, item := range items {
+		fmt.Fprintf(out, "  %s\n", item)
+	}
+}
+
+func (c *networkConfigDescribeCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	networkConfig, err := oclient.HlfV1alpha1().FabricNetworkConfigs(c.ns).Get(context.Background(), c.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	spec := networkConfig.Spec
+	fmt.Fprintf(c.out, "Name:\t\t%s\n", networkConfig.Name)
+	fmt.Fprintf(c.out, "Namespace:\t%s\n", networkConfig.Namespace)
+	fmt.Fprintf(c.out, "Organization:\t%s\n", spec.Organization)
+	fmt.Fprintf(c.out, "Internal:\t%t\n", spec.Internal)
+	fmt.Fprintf(c.out, "Last Refresh:\t%s\n", lastRefresh(*networkConfig))
+	printList(c.out, "Organizations", spec.Organizations)
+	printList(c.out, "Namespaces", spec.Namespaces)
+	printList(c.out, "Channels", spec.Channels)
+	printList(c.out, "Identities", identityNames(*networkConfig))
+	externalNodes, err := helpers.GetNetworkConfigExternalNodes(networkConfig)
+	if err != nil {
+		return err
+	}
+	var externalNodeNames []string
+	for _, peer := range externalNodes.Peers {
+		externalNodeNames = append(externalNodeNames, fmt.Sprintf("peer %s (%s) %s", peer.Name, peer.MSPID, peer.URL))
+	}
+	for _, orderer := range externalNodes.Orderers {
+		externalNodeNames = append(externalNodeNames, fmt.Sprintf("orderer %s (%s) %s", orderer.Name, orderer.MSPID, orderer.URL))
+	}
+	printList(c.out, "External Nodes", externalNodeNames)
+	stored, err := getStoredNetworkConfig(clientSet, networkConfig)
+	if err != nil {
+		fmt.Fprintf(c.out, "Secret:\t\t%s (%v)\n", spec.SecretName, err)
+		return nil
+	}
+	fmt.Fprintf(c.out, "Secret:\t\t%s\n", spec.SecretName)
+	fmt.Fprintf(c.out, "  Client:\t%s\n", stored.Client.Organization)
+	fmt.Fprintf(c.out, "  Peers:\t%d\n", len(stored.Peers))
+	fmt.Fprintf(c.out, "  Orderers:\t%d\n", len(stored.Orderers))
+	fmt.Fprintf(c.out, "  CAs:\t\t%d\n", len(stored.CertificateAuthorities))
+	var users []string
+	for mspID, org := range stored.Organizations {
+		for userName := range org.Users {
+			users = append(users, fmt.Sprintf("%s:%s", mspID, userName))
+		}
+	}
+	sort.Strings(users)
+	fmt.Fprintf(c.out, "  Users:\t%s\n", strings.Join(users, ","))
+	var channels []string
+	for name := range stored.Channels {
+		channels = append(channels, name)
+	}
+	sort.Strings(channels)
+	fmt.Fprintf(c.out, "  Channels:\t%s\n", strings.Join(channels, ","))
+	return nil
+}
@@ -0,0 +1,173 @@
+package networkconfig
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	netcfg "github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/networkconfig"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/inspect"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	networkConfigDiffDesc = `
+'diff' command compares the network config stored in the secret with the current state of the cluster: the URLs and
+TLS CA certificates of the peers, orderers and CAs and the certificates of the users`
+	networkConfigDiffExample = `  kubectl hlf networkconfig diff --name org1-nc --namespace default
+  kubectl hlf networkconfig diff --name org1-nc --namespace default --fix`
+	networkConfigSecretKey = "config.yaml"
+)
+
+type networkConfigDiffCmd struct {
+	out    io.Writer
+	errOut io.Writer
+	name   string
+	ns     string
+	fix    bool
+}
+
+func newDiffNetworkConfigCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &networkConfigDiffCmd{out: out, errOut: errOut}
+
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   "Detect drift between a Network Config secret and the cluster",
+		Long:    networkConfigDiffDesc,
+		Example: networkConfigDiffExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(args)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&c.name, "name", "", "Name of the Network Config to compare")
+	f.StringVarP(&c.ns, "namespace", "n", helpers.DefaultNamespace, "Namespace scope for this request")
+	f.BoolVar(&c.fix, "fix", false, "Refresh the Network Config when it has drifted")
+	return cmd
+}
+
+func (d *networkConfigDiffCmd) validate() error {
+	if d.name == "" {
+		return errors.New("--name flag is required")
+	}
+	return nil
+}
+
+// getStoredNetworkConfig reads the network config the operator wrote in the secret
+func getStoredNetworkConfig(clientSet *kubernetes.Clientset, networkConfig *hlfv1alpha1.FabricNetworkConfig) (*netcfg.NetworkConfig, error) {
+	secret, err := clientSet.CoreV1().Secrets(networkConfig.Namespace).Get(context.Background(), networkConfig.Spec.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting secret %s on namespace %s", networkConfig.Spec.SecretName, networkConfig.Namespace)
+	}
+	data, ok := secret.Data[networkConfigSecretKey]
+	if !ok {
+		return nil, errors.Errorf("secret %s has no %s key", networkConfig.Spec.SecretName, networkConfigSecretKey)
+	}
+	return netcfg.Unmarshal(data)
+}
+
+// getExpectedNetworkConfig generates the network config for the spec of the FabricNetworkConfig from
+// the current state of the cluster, users are named after their FabricIdentity. The generator is not the
+// one of the operator, so only the fields compared by Diff, rendered the same way by both, can be trusted
+func getExpectedNetworkConfig(oclient *operatorv1.Clientset, networkConfig *hlfv1alpha1.FabricNetworkConfig) (*netcfg.NetworkConfig, error) {
+	var identities []string
+	for _, identity := range networkConfig.Spec.Identities {
+		fabricIdentity, err := oclient.HlfV1alpha1().FabricIdentities(identity.Namespace).Get(context.Background(), identity.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting identity %s on namespace %s", identity.Name, identity.Namespace)
+		}
+		identities = append(identities, fmt.Sprintf(
+			"%s:%s=fabricidentity/%s.%s",
+			fabricIdentity.Spec.MSPID,
+			identity.Name,
+			identity.Name,
+			identity.Namespace,
+		))
+	}
+	externalNodes, err := helpers.GetNetworkConfigExternalNodes(networkConfig)
+	if err != nil {
+		return nil, err
+	}
+	channels := networkConfig.Spec.Channels
+	if len(channels) == 0 {
+		channels = []string{"_default"}
+	}
+	return inspect.GenerateNetworkConfig(inspect.Options{
+		Organization:  networkConfig.Spec.Organization,
+		Organizations: networkConfig.Spec.Organizations,
+		Namespaces:    networkConfig.Spec.Namespaces,
+		Channels:      channels,
+		Internal:      networkConfig.Spec.Internal,
+		Identities:    identities,
+		ExternalNodes: externalNodes,
+	}, "")
+}
+
+func (d *networkConfigDiffCmd) run(args []string) error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	networkConfig, err := oclient.HlfV1alpha1().FabricNetworkConfigs(d.ns).Get(context.Background(), d.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	stored, err := getStoredNetworkConfig(clientSet, networkConfig)
+	if err != nil {
+		return err
+	}
+	expected, err := getExpectedNetworkConfig(oclient, networkConfig)
+	if err != nil {
+		return err
+	}
+	differences := netcfg.Diff(stored, expected)
+	if len(differences) == 0 {
+		log.Infof("Network Config %s is up to date", d.name)
+		return nil
+	}
+	var data [][]string
+	for _, difference := range differences {
+		data = append(data, []string{difference.Section, difference.Name, difference.Field, difference.Status})
+	}
+	table := tablewriter.NewWriter(d.out)
+	table.SetHeader([]string{"Section", "Name", "Field", "Status"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	if !d.fix {
+		log.Warnf("Network Config %s has drifted, run with --fix to refresh it", d.name)
+		return nil
+	}
+	err = refreshNetworkConfig(oclient, d.ns, d.name)
+	if err != nil {
+		return err
+	}
+	log.Infof("Network Config %s refreshed", d.name)
+	return nil
+}
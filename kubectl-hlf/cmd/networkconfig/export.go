@@ -3,6 +3,8 @@ package networkconfig
 import (
 	"context"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	netcfg "github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/networkconfig"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/inspect"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"io"
@@ -12,16 +14,19 @@ import (
 
 const (
 	networkConfigExportDesc = `
-'export' command deletes a Hyperledger Fabric Network Config tenant`
-	networkConfigExportExample = `  kubectl hlf networkconfig export --name org1-nc --namespace default --output=connection-org.yaml`
+'export' command exports the network config of a Hyperledger Fabric Network Config, optionally converted into another SDK profile format`
+	networkConfigExportExample = `  kubectl hlf networkconfig export --name org1-nc --namespace default --output=connection-org.yaml
+  kubectl hlf networkconfig export --name org1-nc --namespace default --format fabric-gateway --identity Org1MSP:admin --output=gateway.json`
 )
 
 type networkConfigExportCmd struct {
-	out    io.Writer
-	errOut io.Writer
-	name   string
-	ns     string
-	output string
+	out      io.Writer
+	errOut   io.Writer
+	name     string
+	ns       string
+	output   string
+	format   string
+	identity string
 }
 
 func newExportNetworkConfigCmd(out io.Writer, errOut io.Writer) *cobra.Command {
@@ -44,6 +49,8 @@ func newExportNetworkConfigCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	f.StringVar(&c.name, "name", "", "Name of the Network Config to export")
 	f.StringVarP(&c.ns, "namespace", "n", helpers.DefaultNamespace, "Namespace scope for this request")
 	f.StringVarP(&c.output, "output", "o", "", "File to write the secret")
+	f.StringVar(&c.format, "format", "yaml", "Connection profile format: go-sdk, node-sdk, java-sdk, fabric-gateway or ccp-json, yaml and json are go-sdk profiles")
+	f.StringVar(&c.identity, "identity", "", "Embedded identity in the format MSPID:user to use as the client default")
 	return cmd
 }
 
@@ -72,7 +79,17 @@ func (d *networkConfigExportCmd) run(args []string) error {
 	if err != nil {
 		return err
 	}
-	networkConfigBytes := secret.Data["config.yaml"]
+	networkConfigBytes := secret.Data[networkConfigSecretKey]
+	if d.format != "yaml" || d.identity != "" {
+		config, err := netcfg.Unmarshal(networkConfigBytes)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the network config of %s", d.name)
+		}
+		networkConfigBytes, err = inspect.ConvertNetworkConfig(config, d.format, d.identity)
+		if err != nil {
+			return err
+		}
+	}
 	if d.output != "" {
 		err = ioutil.WriteFile(d.output, networkConfigBytes, 0777)
 		if err != nil {
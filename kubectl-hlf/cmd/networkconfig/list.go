@@ -0,0 +1,91 @@
+package networkconfig
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type networkConfigListCmd struct {
+	out io.Writer
+	ns  string
+}
+
+func newListNetworkConfigCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &networkConfigListCmd{out: out}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List Network Config CRDs",
+		Long:  `List the Network Configs with their organizations, channels, identities and last refresh`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&c.ns, "namespace", "n", "", "Namespace scope for this request, all namespaces when empty")
+	return cmd
+}
+
+// lastRefresh returns the last time the network config was refreshed, the creation time when it
+// was never refreshed
+func lastRefresh(networkConfig hlfv1alpha1.FabricNetworkConfig) string {
+	if refreshTime, ok := networkConfig.Annotations[reloaderAnnotation]; ok {
+		return refreshTime
+	}
+	return networkConfig.CreationTimestamp.Format(time.RFC3339)
+}
+
+func identityNames(networkConfig hlfv1alpha1.FabricNetworkConfig) []string {
+	var names []string
+	for _, identity := range networkConfig.Spec.Identities {
+		names = append(names, fmt.Sprintf("%s.%s", identity.Name, identity.Namespace))
+	}
+	return names
+}
+
+func (c *networkConfigListCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	networkConfigs, err := oclient.HlfV1alpha1().FabricNetworkConfigs(c.ns).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var data [][]string
+	for _, networkConfig := range networkConfigs.Items {
+		data = append(data, []string{
+			networkConfig.Name,
+			networkConfig.Namespace,
+			strings.Join(networkConfig.Spec.Organizations, ","),
+			strings.Join(networkConfig.Spec.Channels, ","),
+			strings.Join(identityNames(networkConfig), ","),
+			fmt.Sprintf("%t", networkConfig.Spec.Internal),
+			lastRefresh(networkConfig),
+		})
+	}
+	table := tablewriter.NewWriter(c.out)
+	table.SetHeader([]string{"Name", "Namespace", "Organizations", "Channels", "Identities", "Internal", "Last Refresh"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
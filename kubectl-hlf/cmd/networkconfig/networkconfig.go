@@ -11,8 +11,11 @@ func NewNetworkConfigCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	}
 	cmd.AddCommand(
 		newCreateNetworkConfigCmd(out, errOut),
+		newDescribeNetworkConfigCmd(out, errOut),
 		newDeleteNetworkConfigCmd(out, errOut),
+		newDiffNetworkConfigCmd(out, errOut),
 		newExportNetworkConfigCmd(out, errOut),
+		newListNetworkConfigCmd(out, errOut),
 		newRefreshNetworkConfigCmd(out, errOut),
 		newUpdateNetworkConfigCmd(out, errOut),
 	)
@@ -3,6 +3,7 @@ package networkconfig
 import (
 	"context"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"io"
@@ -14,6 +15,7 @@ const (
 	networkConfigRefreshDesc = `
 'refresh' command deletes a Hyperledger Fabric Network Config tenant`
 	networkConfigRefreshExample = `  kubectl hlf networkconfig refresh --name org1-nc --namespace default`
+	reloaderAnnotation          = "reloader.hlf.kungfusoftware.es/time"
 )
 
 type networkConfigRefreshCmd struct {
@@ -57,15 +59,20 @@ func (d *networkConfigRefreshCmd) run(args []string) error {
 	if err != nil {
 		return err
 	}
-	networkConfig, err := oclient.HlfV1alpha1().FabricNetworkConfigs(d.ns).Get(context.Background(), d.name, metav1.GetOptions{})
+	return refreshNetworkConfig(oclient, d.ns, d.name)
+}
+
+// refreshNetworkConfig bumps the reloader annotation so the operator regenerates the secret
+func refreshNetworkConfig(oclient *operatorv1.Clientset, ns string, name string) error {
+	networkConfig, err := oclient.HlfV1alpha1().FabricNetworkConfigs(ns).Get(context.Background(), name, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 	if networkConfig.Annotations == nil {
 		networkConfig.Annotations = make(map[string]string)
 	}
-	networkConfig.Annotations["reloader.hlf.kungfusoftware.es/time"] = time.Now().Format(time.RFC3339)
-	_, err = oclient.HlfV1alpha1().FabricNetworkConfigs(d.ns).Update(context.Background(), networkConfig, metav1.UpdateOptions{})
+	networkConfig.Annotations[reloaderAnnotation] = time.Now().Format(time.RFC3339)
+	_, err = oclient.HlfV1alpha1().FabricNetworkConfigs(ns).Update(context.Background(), networkConfig, metav1.UpdateOptions{})
 	if err != nil {
 		return err
 	}
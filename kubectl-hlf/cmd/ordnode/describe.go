@@ -0,0 +1,226 @@
+package ordnode
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/osnadmin"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+type describeOrdererCmd struct {
+	name             string
+	namespace        string
+	identity         string
+	operationsScheme string
+}
+
+func (c *describeOrdererCmd) validate() error {
+	if c.name == "" {
+		return errors.Errorf("--name is required")
+	}
+	if c.namespace == "" {
+		return errors.Errorf("--namespace is required")
+	}
+	return nil
+}
+
+func (c *describeOrdererCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	ordNode, err := oclient.HlfV1alpha1().FabricOrdererNodes(c.namespace).Get(context.Background(), c.name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Name:\t\t%s\n", ordNode.Name)
+	fmt.Printf("Namespace:\t%s\n", ordNode.Namespace)
+	fmt.Printf("MSP ID:\t\t%s\n", ordNode.Spec.MspID)
+	fmt.Printf("Image:\t\t%s:%s\n", ordNode.Spec.Image, ordNode.Spec.Tag)
+	fmt.Printf("Status:\t\t%s\n", ordNode.Status.Status)
+	if ordNode.Status.Message != "" {
+		fmt.Printf("Message:\t%s\n", ordNode.Status.Message)
+	}
+
+	pods, err := helpers.GetNodePods(clientSet, ordNode.Name, ordNode.Namespace)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pods:\n")
+	for _, pod := range pods {
+		fmt.Printf("  %s\tphase=%s ready=%t restarts=%d node=%s\n", pod.Name, pod.Status.Phase, helpers.IsPodReady(pod), helpers.GetPodRestarts(pod), pod.Spec.NodeName)
+		for _, volume := range helpers.GetPodVolumeUsage(clientSet, pod) {
+			if volume.Error != "" {
+				fmt.Printf("    PVC %s\terror=%s\n", volume.PVC, volume.Error)
+				continue
+			}
+			fmt.Printf("    PVC %s\trequested=%s used=%s capacity=%s\n", volume.PVC, volume.Requested, helpers.FormatBytes(volume.UsedBytes), helpers.FormatBytes(volume.CapacityBytes))
+		}
+	}
+
+	fmt.Printf("Operations:\n")
+	for _, path := range []string{"/healthz", "/version"} {
+		res, err := helpers.GetOperationsEndpoint(clientSet, c.operationsScheme, ordNode.Name, ordNode.Namespace, helpers.OrdererOperationsPort, path)
+		if err != nil {
+			fmt.Printf("  %s\terror: %v\n", path, err)
+			continue
+		}
+		fmt.Printf("  %s\t%s\n", path, strings.TrimSpace(string(res)))
+	}
+
+	hostPort, err := helpers.GetOrdererHostPort(clientSet, *ordNode)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("TLS:\n")
+	hostCheck, err := helpers.CheckCertificateHost(ordNode.Status.TlsCert, hostPort.Host)
+	if err != nil {
+		fmt.Printf("  error: %v\n", err)
+	} else {
+		fmt.Printf("  Public host:\t%s:%d\n", hostPort.Host, hostPort.Port)
+		fmt.Printf("  DNS SANs:\t%s\n", strings.Join(hostCheck.DNSNames, ","))
+		fmt.Printf("  IP SANs:\t%s\n", strings.Join(hostCheck.IPs, ","))
+		if hostCheck.Matches {
+			fmt.Printf("  Host match:\tOK\n")
+		} else {
+			fmt.Printf("  Host match:\tMISMATCH, the TLS certificate is not valid for %s\n", hostPort.Host)
+		}
+	}
+
+	if c.identity == "" {
+		return nil
+	}
+	adminHost, adminPort, err := helpers.GetOrdererAdminHostAndPort(clientSet, ordNode.Spec, ordNode.Status)
+	if err != nil {
+		return err
+	}
+	return c.printChannels(fmt.Sprintf("https://%s:%d", adminHost, adminPort), ordNode.Status.TlsCert, ordNode.Status.TlsAdminCert)
+}
+
+func (c *describeOrdererCmd) printChannels(osnURL string, tlsCert string, tlsAdminCert string) error {
+	certPool := x509.NewCertPool()
+	ok := certPool.AppendCertsFromPEM([]byte(tlsCert))
+	if !ok {
+		return errors.Errorf("failed to add certificate")
+	}
+	ok = certPool.AppendCertsFromPEM([]byte(tlsAdminCert))
+	if !ok {
+		return errors.Errorf("failed to add certificate")
+	}
+	identityBytes, err := ioutil.ReadFile(c.identity)
+	if err != nil {
+		return err
+	}
+	id := &identity{}
+	err = yaml.Unmarshal(identityBytes, id)
+	if err != nil {
+		return err
+	}
+	tlsClientCert, err := tls.X509KeyPair(
+		[]byte(id.Cert.Pem),
+		[]byte(id.Key.Pem),
+	)
+	if err != nil {
+		return err
+	}
+	chResponse, err := osnadmin.ListAllChannels(osnURL, certPool, tlsClientCert)
+	if err != nil {
+		return err
+	}
+	defer chResponse.Body.Close()
+	if chResponse.StatusCode != 200 {
+		return errors.Errorf("error listing channels, got status code=%d", chResponse.StatusCode)
+	}
+	channelList := &osnadmin.ChannelList{}
+	err = json.NewDecoder(chResponse.Body).Decode(channelList)
+	if err != nil {
+		return err
+	}
+	data := [][]string{}
+	for _, channel := range channelList.Channels {
+		chInfo, err := getChannelInfo(osnURL, channel.Name, certPool, tlsClientCert)
+		if err != nil {
+			data = append(data, []string{channel.Name, "-", "-", "-", err.Error()})
+			continue
+		}
+		data = append(data, []string{
+			chInfo.Name,
+			string(chInfo.ConsensusRelation),
+			string(chInfo.Status),
+			fmt.Sprintf("%d", chInfo.Height),
+			"",
+		})
+	}
+	fmt.Printf("Channels:\n")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Channel", "Relation", "Status", "Height", "Error"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
+
+func getChannelInfo(osnURL string, channelID string, certPool *x509.CertPool, tlsClientCert tls.Certificate) (*osnadmin.ChannelInfo, error) {
+	chResponse, err := osnadmin.ListSingleChannel(osnURL, channelID, certPool, tlsClientCert)
+	if err != nil {
+		return nil, err
+	}
+	defer chResponse.Body.Close()
+	if chResponse.StatusCode != 200 {
+		return nil, errors.Errorf("got status code=%d", chResponse.StatusCode)
+	}
+	chInfo := &osnadmin.ChannelInfo{}
+	err = json.NewDecoder(chResponse.Body).Decode(chInfo)
+	if err != nil {
+		return nil, err
+	}
+	return chInfo, nil
+}
+
+func newDescribeOrdererCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &describeOrdererCmd{}
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Describe an orderer node with live diagnostics",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.name, "name", "", "", "Orderer node name")
+	persistentFlags.StringVarP(&c.namespace, "namespace", "", "default", "Namespace scope for this request")
+	persistentFlags.StringVarP(&c.identity, "identity", "", "", "Admin identity used to list the channels through the channel participation API")
+	persistentFlags.StringVarP(&c.operationsScheme, "operations-scheme", "", "http", "Scheme of the operations endpoint of the orderer (http/https)")
+	cmd.MarkPersistentFlagRequired("name")
+	return cmd
+}
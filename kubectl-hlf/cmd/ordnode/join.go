@@ -5,21 +5,31 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/osnadmin"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"io"
 	"io/ioutil"
+	"k8s.io/client-go/kubernetes"
+	"os"
 	"sigs.k8s.io/yaml"
+	"sync"
 )
 
 type joinChannelCmd struct {
-	block     string
-	name      string
-	namespace string
-	identity  string
+	block       string
+	name        string
+	namespace   string
+	identity    string
+	mspID       string
+	all         bool
+	concurrency int
 }
 type identity struct {
 	Cert Pem `json:"cert"`
@@ -30,11 +40,20 @@ type Pem struct {
 }
 
 func (c *joinChannelCmd) validate() error {
-	if c.namespace == "" {
-		return errors.Errorf("--namespace is required")
-	}
-	if c.name == "" {
-		return errors.Errorf("--name is required")
+	if c.all {
+		if c.mspID == "" {
+			return errors.Errorf("--mspid is required with --all")
+		}
+		if c.concurrency < 1 {
+			return errors.Errorf("--concurrency must be greater than 0")
+		}
+	} else {
+		if c.namespace == "" {
+			return errors.Errorf("--namespace is required")
+		}
+		if c.name == "" {
+			return errors.Errorf("--name is required")
+		}
 	}
 	if c.identity == "" {
 		return errors.Errorf("--identity is required")
@@ -53,21 +72,6 @@ func (c *joinChannelCmd) run() error {
 	if err != nil {
 		return err
 	}
-	log.Printf("name=%s namespace=%s", c.name, c.namespace)
-	ordererNode, err := helpers.GetOrdererNodeByFullName(clientSet, hlfClient, fmt.Sprintf("%s.%s", c.name, c.namespace))
-	if err != nil {
-		return err
-	}
-	certPool := x509.NewCertPool()
-	ok := certPool.AppendCertsFromPEM([]byte(ordererNode.Status.TlsCert))
-	if !ok {
-		return errors.Errorf("failed to add certificate")
-	}
-	adminTlsCert := ordererNode.Status.TlsAdminCert
-	ok = certPool.AppendCertsFromPEM([]byte(adminTlsCert))
-	if !ok {
-		return errors.Errorf("failed to add certificate")
-	}
 	identityBytes, err := ioutil.ReadFile(c.identity)
 	if err != nil {
 		return err
@@ -84,14 +88,49 @@ func (c *joinChannelCmd) run() error {
 	if err != nil {
 		return err
 	}
+	blockBytes, err := ioutil.ReadFile(c.block)
+	if err != nil {
+		return err
+	}
+	if c.all {
+		return c.joinAll(clientSet, hlfClient, blockBytes, tlsClientCert)
+	}
+	log.Printf("name=%s namespace=%s", c.name, c.namespace)
+	ordererNode, err := helpers.GetOrdererNodeByFullName(clientSet, hlfClient, fmt.Sprintf("%s.%s", c.name, c.namespace))
+	if err != nil {
+		return err
+	}
+	return joinOrderer(clientSet, ordererNode, blockBytes, tlsClientCert)
+}
+
+func getOrdererCertPool(ordererNode *helpers.ClusterOrdererNode) (*x509.CertPool, error) {
+	certPool := x509.NewCertPool()
+	ok := certPool.AppendCertsFromPEM([]byte(ordererNode.Status.TlsCert))
+	if !ok {
+		return nil, errors.Errorf("failed to add certificate")
+	}
+	adminTlsCert := ordererNode.Status.TlsAdminCert
+	ok = certPool.AppendCertsFromPEM([]byte(adminTlsCert))
+	if !ok {
+		return nil, errors.Errorf("failed to add certificate")
+	}
+	return certPool, nil
+}
 
+func getOrdererAdminURL(clientSet *kubernetes.Clientset, ordererNode *helpers.ClusterOrdererNode) (string, error) {
 	ordererHostName, adminPort, err := helpers.GetOrdererAdminHostAndPort(clientSet, ordererNode.Spec, ordererNode.Status)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return fmt.Sprintf("https://%s:%d", ordererHostName, adminPort), nil
+}
 
-	osnUrl := fmt.Sprintf("https://%s:%d", ordererHostName, adminPort)
-	blockBytes, err := ioutil.ReadFile(c.block)
+func joinOrderer(clientSet *kubernetes.Clientset, ordererNode *helpers.ClusterOrdererNode, blockBytes []byte, tlsClientCert tls.Certificate) error {
+	certPool, err := getOrdererCertPool(ordererNode)
+	if err != nil {
+		return err
+	}
+	osnUrl, err := getOrdererAdminURL(clientSet, ordererNode)
 	if err != nil {
 		return err
 	}
@@ -118,6 +157,139 @@ func (c *joinChannelCmd) run() error {
 	return nil
 }
 
+// getChannelIDFromBlock returns the channel ID in the header of the first envelope of the block
+func getChannelIDFromBlock(blockBytes []byte) (string, error) {
+	block := &common.Block{}
+	err := proto.Unmarshal(blockBytes, block)
+	if err != nil {
+		return "", err
+	}
+	if block.Data == nil || len(block.Data.Data) == 0 {
+		return "", errors.Errorf("block has no data")
+	}
+	envelope := &common.Envelope{}
+	err = proto.Unmarshal(block.Data.Data[0], envelope)
+	if err != nil {
+		return "", err
+	}
+	payload := &common.Payload{}
+	err = proto.Unmarshal(envelope.Payload, payload)
+	if err != nil {
+		return "", err
+	}
+	if payload.Header == nil {
+		return "", errors.Errorf("block envelope has no header")
+	}
+	channelHeader := &common.ChannelHeader{}
+	err = proto.Unmarshal(payload.Header.ChannelHeader, channelHeader)
+	if err != nil {
+		return "", err
+	}
+	return channelHeader.ChannelId, nil
+}
+
+type joinResult struct {
+	node   string
+	status string
+	err    error
+}
+
+// joinAll joins every orderer node of the organization that is not yet part of the channel
+func (c *joinChannelCmd) joinAll(clientSet *kubernetes.Clientset, hlfClient *operatorv1.Clientset, blockBytes []byte, tlsClientCert tls.Certificate) error {
+	channelID, err := getChannelIDFromBlock(blockBytes)
+	if err != nil {
+		return err
+	}
+	ordererNodes, err := helpers.GetClusterOrdererNodes(clientSet, hlfClient, "")
+	if err != nil {
+		return err
+	}
+	var nodes []*helpers.ClusterOrdererNode
+	for _, ordererNode := range ordererNodes {
+		if ordererNode.Spec.MspID == c.mspID {
+			nodes = append(nodes, ordererNode)
+		}
+	}
+	if len(nodes) == 0 {
+		return errors.Errorf("no orderer nodes found for MSP ID %s", c.mspID)
+	}
+	results := make([]joinResult, len(nodes))
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, ordererNode := range nodes {
+		wg.Add(1)
+		go func(i int, ordererNode *helpers.ClusterOrdererNode) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = joinOrdererIfNeeded(clientSet, ordererNode, channelID, blockBytes, tlsClientCert)
+		}(i, ordererNode)
+	}
+	wg.Wait()
+
+	data := [][]string{}
+	failed := 0
+	for _, result := range results {
+		errMsg := ""
+		if result.err != nil {
+			failed++
+			errMsg = result.err.Error()
+		}
+		data = append(data, []string{result.node, result.status, errMsg})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Orderer", "Result", "Error"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	if failed > 0 {
+		return errors.Errorf("%d of %d orderer nodes failed to join the channel %s", failed, len(results), channelID)
+	}
+	return nil
+}
+
+func joinOrdererIfNeeded(clientSet *kubernetes.Clientset, ordererNode *helpers.ClusterOrdererNode, channelID string, blockBytes []byte, tlsClientCert tls.Certificate) joinResult {
+	certPool, err := getOrdererCertPool(ordererNode)
+	if err != nil {
+		return joinResult{node: ordererNode.Name, status: "Failed", err: err}
+	}
+	osnUrl, err := getOrdererAdminURL(clientSet, ordererNode)
+	if err != nil {
+		return joinResult{node: ordererNode.Name, status: "Failed", err: err}
+	}
+	chResponse, err := osnadmin.ListSingleChannel(osnUrl, channelID, certPool, tlsClientCert)
+	if err != nil {
+		return joinResult{node: ordererNode.Name, status: "Failed", err: err}
+	}
+	chResponse.Body.Close()
+	if chResponse.StatusCode == 200 {
+		return joinResult{node: ordererNode.Name, status: "Skipped (already joined)"}
+	}
+	// only join when the orderer reports it's not part of the channel
+	if chResponse.StatusCode != 404 {
+		return joinResult{
+			node:   ordererNode.Name,
+			status: "Failed",
+			err:    errors.Errorf("error getting channel %s, got status code=%d", channelID, chResponse.StatusCode),
+		}
+	}
+	err = joinOrderer(clientSet, ordererNode, blockBytes, tlsClientCert)
+	if err != nil {
+		return joinResult{node: ordererNode.Name, status: "Failed", err: err}
+	}
+	return joinResult{node: ordererNode.Name, status: "Joined"}
+}
+
 func newJoinChannelCMD(io.Writer, io.Writer) *cobra.Command {
 	c := &joinChannelCmd{}
 	cmd := &cobra.Command{
@@ -134,9 +306,10 @@ func newJoinChannelCMD(io.Writer, io.Writer) *cobra.Command {
 	persistentFlags.StringVarP(&c.block, "block", "", "", "Block")
 	persistentFlags.StringVarP(&c.name, "name", "", "", "Orderer Service name")
 	persistentFlags.StringVarP(&c.namespace, "namespace", "", "default", "Namespace scope for this request")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization whose orderer nodes are joined with --all")
+	persistentFlags.BoolVarP(&c.all, "all", "", false, "Join all the orderer nodes of the organization")
+	persistentFlags.IntVarP(&c.concurrency, "concurrency", "", 4, "Maximum number of orderer nodes joined at the same time")
 	cmd.MarkPersistentFlagRequired("identity")
 	cmd.MarkPersistentFlagRequired("block")
-	cmd.MarkPersistentFlagRequired("name")
-	cmd.MarkPersistentFlagRequired("namespace")
 	return cmd
 }
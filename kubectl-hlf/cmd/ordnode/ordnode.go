@@ -3,6 +3,7 @@ package ordnode
 import (
 	"io"
 
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/ordnode/raft"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +20,8 @@ func NewOrdNodeCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 		newRemoveChannelCMD(out, errOut),
 		newUpgradeOrdererCMD(out, errOut),
 		newUpdateOrdererCMD(out, errOut),
+		newDescribeOrdererCMD(out, errOut),
+		raft.NewRaftCmd(out, errOut),
 	)
 	return cmd
 }
@@ -0,0 +1,17 @@
+package raft
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func NewRaftCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	raftCmd := &cobra.Command{
+		Use: "raft",
+	}
+	raftCmd.AddCommand(
+		newRaftStatusCMD(stdOut, stdErr),
+	)
+	return raftCmd
+}
@@ -0,0 +1,486 @@
+package raft
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/osnadmin"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/expfmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	metricIsLeader       = "consensus_etcdraft_is_leader"
+	metricActiveNodes    = "consensus_etcdraft_active_nodes"
+	metricClusterSize    = "consensus_etcdraft_cluster_size"
+	metricCommittedBlock = "consensus_etcdraft_committed_block_number"
+)
+
+type identity struct {
+	Cert Pem `json:"cert"`
+	Key  Pem `json:"key"`
+}
+type Pem struct {
+	Pem string
+}
+
+type consenterStatus struct {
+	Address       string
+	Node          *hlfv1alpha1.FabricOrdererNode
+	Participation *osnadmin.ChannelInfo
+	Metrics       map[string]float64
+	Errors        []string
+}
+
+func (s consenterStatus) nodeName() string {
+	if s.Node == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s", s.Node.Name, s.Node.Namespace)
+}
+
+func (s consenterStatus) isLeader() bool {
+	return s.Metrics[metricIsLeader] == 1
+}
+
+func (s consenterStatus) isHealthy() bool {
+	if s.Node == nil {
+		return false
+	}
+	if s.Participation != nil {
+		return s.Participation.Status == osnadmin.StatusActive
+	}
+	_, ok := s.Metrics[metricCommittedBlock]
+	return ok
+}
+
+func (s consenterStatus) height() (uint64, bool) {
+	if s.Participation != nil {
+		return s.Participation.Height, true
+	}
+	if committedBlock, ok := s.Metrics[metricCommittedBlock]; ok {
+		return uint64(committedBlock) + 1, true
+	}
+	return 0, false
+}
+
+type raftStatusCmd struct {
+	configPath       string
+	userName         string
+	mspID            string
+	channelName      string
+	identity         string
+	operationsScheme string
+	maxLag           uint64
+}
+
+func (c *raftStatusCmd) validate() error {
+	if c.mspID == "" {
+		return errors.Errorf("--mspid is required")
+	}
+	if c.channelName == "" && c.identity == "" {
+		return errors.Errorf("--identity is required to discover the channels when --channel is not set")
+	}
+	return nil
+}
+
+func (c *raftStatusCmd) run() error {
+	oClient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	ordNodes, err := oClient.HlfV1alpha1().FabricOrdererNodes("").List(context.Background(), v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var certPool *x509.CertPool
+	var tlsClientCert tls.Certificate
+	if c.identity != "" {
+		certPool, tlsClientCert, err = c.getOSNAdminCredentials(ordNodes.Items)
+		if err != nil {
+			return err
+		}
+	}
+	channels := []string{c.channelName}
+	if c.channelName == "" {
+		channels, err = c.discoverChannels(clientSet, ordNodes.Items, certPool, tlsClientCert)
+		if err != nil {
+			return err
+		}
+	}
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(c.mspID),
+	))
+	if err != nil {
+		return err
+	}
+	for _, channelID := range channels {
+		block, err := resClient.QueryConfigBlockFromOrderer(channelID)
+		if err != nil {
+			return err
+		}
+		cfgBlock, err := resource.ExtractConfigFromBlock(block)
+		if err != nil {
+			return err
+		}
+		cftxGen := configtx.New(cfgBlock)
+		ordConfig, err := cftxGen.Orderer().Configuration()
+		if err != nil {
+			return err
+		}
+		var statuses []consenterStatus
+		for _, consenter := range ordConfig.EtcdRaft.Consenters {
+			status := consenterStatus{
+				Address: fmt.Sprintf("%s:%d", consenter.Address.Host, consenter.Address.Port),
+				Metrics: map[string]float64{},
+			}
+			status.Node = matchOrdererNode(clientSet, ordNodes.Items, consenter.ServerTLSCert, consenter.Address.Host, consenter.Address.Port)
+			if status.Node != nil {
+				c.collectNodeStatus(clientSet, channelID, &status, certPool, tlsClientCert)
+			}
+			statuses = append(statuses, status)
+		}
+		c.printChannelStatus(channelID, string(ordConfig.State), statuses)
+	}
+	return nil
+}
+
+func (c *raftStatusCmd) getOSNAdminCredentials(ordNodes []hlfv1alpha1.FabricOrdererNode) (*x509.CertPool, tls.Certificate, error) {
+	identityBytes, err := ioutil.ReadFile(c.identity)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+	id := &identity{}
+	err = yaml.Unmarshal(identityBytes, id)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+	tlsClientCert, err := tls.X509KeyPair(
+		[]byte(id.Cert.Pem),
+		[]byte(id.Key.Pem),
+	)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+	certPool := x509.NewCertPool()
+	for _, ordNode := range ordNodes {
+		certPool.AppendCertsFromPEM([]byte(ordNode.Status.TlsCert))
+		certPool.AppendCertsFromPEM([]byte(ordNode.Status.TlsAdminCert))
+	}
+	return certPool, tlsClientCert, nil
+}
+
+// discoverChannels returns the channels the orderer nodes of the organization participate in
+func (c *raftStatusCmd) discoverChannels(
+	clientSet *kubernetes.Clientset,
+	ordNodes []hlfv1alpha1.FabricOrdererNode,
+	certPool *x509.CertPool,
+	tlsClientCert tls.Certificate,
+) ([]string, error) {
+	channelSet := map[string]bool{}
+	for _, ordNode := range ordNodes {
+		if ordNode.Spec.MspID != c.mspID {
+			continue
+		}
+		osnURL, err := getOSNAdminURL(clientSet, ordNode)
+		if err != nil {
+			log.Warnf("Failed to get the admin URL of %s.%s: %v", ordNode.Name, ordNode.Namespace, err)
+			continue
+		}
+		chResponse, err := osnadmin.ListAllChannels(osnURL, certPool, tlsClientCert)
+		if err != nil {
+			log.Warnf("Failed to list the channels of %s.%s: %v", ordNode.Name, ordNode.Namespace, err)
+			continue
+		}
+		if chResponse.StatusCode != 200 {
+			chResponse.Body.Close()
+			log.Warnf("Failed to list the channels of %s.%s, got status code=%d", ordNode.Name, ordNode.Namespace, chResponse.StatusCode)
+			continue
+		}
+		channelList := &osnadmin.ChannelList{}
+		err = json.NewDecoder(chResponse.Body).Decode(channelList)
+		chResponse.Body.Close()
+		if err != nil {
+			log.Warnf("Failed to decode the channels of %s.%s: %v", ordNode.Name, ordNode.Namespace, err)
+			continue
+		}
+		for _, channel := range channelList.Channels {
+			channelSet[channel.Name] = true
+		}
+	}
+	var channels []string
+	for channel := range channelSet {
+		channels = append(channels, channel)
+	}
+	if len(channels) == 0 {
+		return nil, errors.Errorf("no channels found for the orderer nodes of %s", c.mspID)
+	}
+	sort.Strings(channels)
+	return channels, nil
+}
+
+func getOSNAdminURL(clientSet *kubernetes.Clientset, ordNode hlfv1alpha1.FabricOrdererNode) (string, error) {
+	adminHost, adminPort, err := helpers.GetOrdererAdminHostAndPort(clientSet, ordNode.Spec, ordNode.Status)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s:%d", adminHost, adminPort), nil
+}
+
+// matchOrdererNode finds the orderer node of a consenter, first by TLS certificate and then by host and port
+func matchOrdererNode(
+	clientSet *kubernetes.Clientset,
+	ordNodes []hlfv1alpha1.FabricOrdererNode,
+	serverTLSCert *x509.Certificate,
+	host string,
+	port int,
+) *hlfv1alpha1.FabricOrdererNode {
+	for i, ordNode := range ordNodes {
+		if serverTLSCert == nil || ordNode.Status.TlsCert == "" {
+			continue
+		}
+		tlsCert, err := utils.ParseX509Certificate([]byte(ordNode.Status.TlsCert))
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(tlsCert.Raw, serverTLSCert.Raw) {
+			return &ordNodes[i]
+		}
+	}
+	for i, ordNode := range ordNodes {
+		hostPort, err := helpers.GetOrdererHostPort(clientSet, ordNode)
+		if err != nil {
+			continue
+		}
+		if hostPort.Host == host && hostPort.Port == port {
+			return &ordNodes[i]
+		}
+	}
+	return nil
+}
+
+func (c *raftStatusCmd) collectNodeStatus(
+	clientSet *kubernetes.Clientset,
+	channelID string,
+	status *consenterStatus,
+	certPool *x509.CertPool,
+	tlsClientCert tls.Certificate,
+) {
+	metrics, err := getRaftMetrics(clientSet, c.operationsScheme, *status.Node, channelID)
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("metrics: %v", err))
+	} else {
+		status.Metrics = metrics
+	}
+	if certPool == nil {
+		return
+	}
+	osnURL, err := getOSNAdminURL(clientSet, *status.Node)
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("osnadmin: %v", err))
+		return
+	}
+	chResponse, err := osnadmin.ListSingleChannel(osnURL, channelID, certPool, tlsClientCert)
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("osnadmin: %v", err))
+		return
+	}
+	defer chResponse.Body.Close()
+	if chResponse.StatusCode != 200 {
+		status.Errors = append(status.Errors, fmt.Sprintf("osnadmin: got status code=%d", chResponse.StatusCode))
+		return
+	}
+	chInfo := &osnadmin.ChannelInfo{}
+	err = json.NewDecoder(chResponse.Body).Decode(chInfo)
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("osnadmin: %v", err))
+		return
+	}
+	status.Participation = chInfo
+}
+
+// getRaftMetrics reads the etcdraft metrics of a channel from the operations endpoint of the orderer
+func getRaftMetrics(clientSet *kubernetes.Clientset, scheme string, ordNode hlfv1alpha1.FabricOrdererNode, channelID string) (map[string]float64, error) {
+	res, err := helpers.GetOperationsEndpoint(clientSet, scheme, ordNode.Name, ordNode.Namespace, helpers.OrdererOperationsPort, "/metrics")
+	if err != nil {
+		return nil, err
+	}
+	parser := expfmt.TextParser{}
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(res))
+	if err != nil {
+		return nil, err
+	}
+	metrics := map[string]float64{}
+	for _, name := range []string{metricIsLeader, metricActiveNodes, metricClusterSize, metricCommittedBlock} {
+		family, ok := families[name]
+		if !ok {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "channel" && label.GetValue() == channelID {
+					metrics[name] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return metrics, nil
+}
+
+func formatMetric(metrics map[string]float64, name string) string {
+	value, ok := metrics[name]
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", value)
+}
+
+func (c *raftStatusCmd) printChannelStatus(channelID string, state string, statuses []consenterStatus) {
+	var maxHeight uint64
+	for _, status := range statuses {
+		if height, ok := status.height(); ok && height > maxHeight {
+			maxHeight = height
+		}
+	}
+	var leaders, lagging, orphans []string
+	healthy := 0
+	data := [][]string{}
+	for _, status := range statuses {
+		relation, participationStatus, height, lag := "-", "-", "-", "-"
+		if status.Participation != nil {
+			relation = string(status.Participation.ConsensusRelation)
+			participationStatus = string(status.Participation.Status)
+		}
+		if h, ok := status.height(); ok {
+			height = fmt.Sprintf("%d", h)
+			lag = fmt.Sprintf("%d", maxHeight-h)
+			if maxHeight-h > c.maxLag {
+				lagging = append(lagging, status.Address)
+			}
+		}
+		if status.isLeader() {
+			leaders = append(leaders, status.Address)
+		}
+		if status.isHealthy() {
+			healthy++
+		}
+		nodeName := status.nodeName()
+		if status.Node == nil {
+			nodeName = "<orphan>"
+			orphans = append(orphans, status.Address)
+		}
+		data = append(data, []string{
+			status.Address,
+			nodeName,
+			relation,
+			participationStatus,
+			height,
+			lag,
+			fmt.Sprintf("%t", status.isLeader()),
+			formatMetric(status.Metrics, metricActiveNodes),
+			formatMetric(status.Metrics, metricClusterSize),
+			strings.Join(status.Errors, "; "),
+		})
+	}
+	fmt.Printf("Channel: %s (consensus state %s)\n", channelID, state)
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Consenter", "Node", "Relation", "Status", "Height", "Lag", "Leader", "Active Nodes", "Cluster Size", "Errors"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+
+	consenters := len(statuses)
+	quorum := consenters/2 + 1
+	leader := "<none>"
+	if len(leaders) > 0 {
+		leader = strings.Join(leaders, ",")
+	}
+	fmt.Printf("Leader:\t\t\t%s\n", leader)
+	fmt.Printf("Lagging followers:\t%s\n", strings.Join(lagging, ","))
+	fmt.Printf("Orphan consenters:\t%s\n", strings.Join(orphans, ","))
+	quorumStatus := "OK"
+	if healthy < quorum {
+		quorumStatus = "LOST"
+	}
+	fmt.Printf("Quorum:\t\t\t%s (%d/%d healthy, %d needed)\n", quorumStatus, healthy, consenters, quorum)
+	if consenters > 1 {
+		// removing a healthy consenter is the worst case
+		quorumAfterRemoval := (consenters-1)/2 + 1
+		removalStatus := "SAFE"
+		if healthy-1 < quorumAfterRemoval {
+			removalStatus = "UNSAFE"
+		}
+		fmt.Printf("Remove a consenter:\t%s (%d/%d healthy, %d needed)\n", removalStatus, healthy-1, consenters-1, quorumAfterRemoval)
+	}
+	fmt.Println()
+}
+
+func newRaftStatusCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &raftStatusCmd{}
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the health of the raft cluster of the channels",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.channelName, "channel", "", "", "Channel name, all the channels of the orderer nodes of the organization if empty")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the orderer organization")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.identity, "identity", "", "", "Admin identity used to query the channel participation API")
+	persistentFlags.StringVarP(&c.operationsScheme, "operations-scheme", "", "http", "Scheme of the operations endpoint of the orderers (http/https)")
+	persistentFlags.Uint64VarP(&c.maxLag, "max-lag", "", 10, "Number of blocks behind the highest consenter to consider a follower lagging")
+	cmd.MarkPersistentFlagRequired("config")
+	cmd.MarkPersistentFlagRequired("user")
+	cmd.MarkPersistentFlagRequired("mspid")
+	return cmd
+}
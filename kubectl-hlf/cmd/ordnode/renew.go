@@ -1,19 +1,46 @@
 package ordnode
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"github.com/gogo/protobuf/proto"
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-config/configtx/orderer"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/osnadmin"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"io"
+	"io/ioutil"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"os"
+	"path/filepath"
+	"sigs.k8s.io/yaml"
 	"time"
 )
 
 type renewChannelCmd struct {
-	name      string
-	namespace string
+	name       string
+	namespace  string
+	configPath string
+	userName   string
+	mspID      string
+	identity   string
+	channels   []string
+	outputDir  string
+	submit     bool
+	timeout    time.Duration
 }
 
 func (c *renewChannelCmd) validate() error {
@@ -23,6 +50,17 @@ func (c *renewChannelCmd) validate() error {
 	if c.name == "" {
 		return errors.Errorf("--name is required")
 	}
+	if c.configPath != "" {
+		if c.userName == "" || c.mspID == "" {
+			return errors.Errorf("--user and --mspid are required to update the consenters")
+		}
+		if len(c.channels) == 0 && c.identity == "" {
+			return errors.Errorf("--channels or --identity is required to update the consenters")
+		}
+		if c.outputDir == "" && !c.submit {
+			return errors.Errorf("--output-dir or --submit is required to update the consenters")
+		}
+	}
 	return nil
 }
 func (c *renewChannelCmd) run() error {
@@ -30,6 +68,10 @@ func (c *renewChannelCmd) run() error {
 	if err != nil {
 		return err
 	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
 	log.Infof("name=%s namespace=%s", c.name, c.namespace)
 	now := v1.NewTime(time.Now())
 	ctx := context.Background()
@@ -37,15 +79,221 @@ func (c *renewChannelCmd) run() error {
 	if err != nil {
 		return err
 	}
+	oldTlsCert := ordererNode.Status.TlsCert
+	var resClient *resmgmt.Client
+	var channels []string
+	if c.configPath != "" {
+		sdk, err := fabsdk.New(config.FromFile(c.configPath))
+		if err != nil {
+			return err
+		}
+		defer sdk.Close()
+		resClient, err = resmgmt.New(sdk.Context(
+			fabsdk.WithUser(c.userName),
+			fabsdk.WithOrg(c.mspID),
+		))
+		if err != nil {
+			return err
+		}
+		channels = c.channels
+		if len(channels) == 0 {
+			channels, err = c.listChannels(clientSet, oldTlsCert)
+			if err != nil {
+				return err
+			}
+		}
+		// the consenters can't be updated while a channel is in maintenance, so the certificate isn't
+		// renewed unless all the channels can be updated
+		for _, channelID := range channels {
+			cfgBlock, err := helpers.GetCurrentConfigFromPeer(resClient, channelID)
+			if err != nil {
+				return err
+			}
+			cftxGen := configtx.New(cfgBlock)
+			if err := helpers.CheckOrdererNotInMaintenance(&cftxGen, channelID); err != nil {
+				return err
+			}
+		}
+	}
 	ordererNode.Spec.UpdateCertificateTime = &now
 	_, err = hlfClient.HlfV1alpha1().FabricOrdererNodes(c.namespace).Update(ctx, ordererNode, v1.UpdateOptions{})
 	if err != nil {
 		return err
 	}
 	log.Infof("Renewed certificate for orderer node %s", c.name)
+	if c.configPath == "" {
+		return nil
+	}
+	return c.updateConsenters(resClient, channels, oldTlsCert)
+}
+
+// updateConsenters generates, for every channel where the orderer is a consenter, the config update
+// replacing the old TLS certificate of the consenter with the renewed one
+func (c *renewChannelCmd) updateConsenters(resClient *resmgmt.Client, channels []string, oldTlsCert string) error {
+	hlfClient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	oldCert, err := utils.ParseX509Certificate([]byte(oldTlsCert))
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	var newTlsCert string
+	deadline := time.Now().Add(c.timeout)
+	for {
+		ordererNode, err := hlfClient.HlfV1alpha1().FabricOrdererNodes(c.namespace).Get(ctx, c.name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if ordererNode.Status.TlsCert != "" && ordererNode.Status.TlsCert != oldTlsCert {
+			newTlsCert = ordererNode.Status.TlsCert
+			break
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("timed out waiting for the renewed TLS certificate of %s", c.name)
+		}
+		log.Infof("Waiting for the renewed TLS certificate of %s", c.name)
+		time.Sleep(5 * time.Second)
+	}
+	newCert, err := utils.ParseX509Certificate([]byte(newTlsCert))
+	if err != nil {
+		return err
+	}
+	if c.outputDir != "" {
+		err = os.MkdirAll(c.outputDir, 0755)
+		if err != nil {
+			return err
+		}
+	}
+	for _, channelID := range channels {
+		cfgBlock, err := helpers.GetCurrentConfigFromPeer(resClient, channelID)
+		if err != nil {
+			return err
+		}
+		cftxGen := configtx.New(cfgBlock)
+		cfgOrd := cftxGen.Orderer()
+		ordConf, err := cfgOrd.Configuration()
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, consenter := range ordConf.EtcdRaft.Consenters {
+			if consenter.ServerTLSCert == nil || !bytes.Equal(consenter.ServerTLSCert.Raw, oldCert.Raw) {
+				continue
+			}
+			found = true
+			err = cfgOrd.RemoveConsenter(consenter)
+			if err != nil {
+				return err
+			}
+			err = cfgOrd.AddConsenter(orderer.Consenter{
+				Address:       consenter.Address,
+				ClientTLSCert: newCert,
+				ServerTLSCert: newCert,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if !found {
+			log.Infof("Orderer %s is not a consenter of %s with the old certificate, skipping", c.name, channelID)
+			continue
+		}
+		configUpdateBytes, err := cftxGen.ComputeMarshaledUpdate(channelID)
+		if err != nil {
+			return err
+		}
+		configUpdate := &common.ConfigUpdate{}
+		err = proto.Unmarshal(configUpdateBytes, configUpdate)
+		if err != nil {
+			return err
+		}
+		channelConfigBytes, err := helpers.CreateConfigUpdateEnvelope(channelID, configUpdate)
+		if err != nil {
+			return err
+		}
+		if c.outputDir != "" {
+			output := filepath.Join(c.outputDir, fmt.Sprintf("%s_%s_consenter_update.pb", channelID, c.name))
+			err = ioutil.WriteFile(output, channelConfigBytes, 0644)
+			if err != nil {
+				return err
+			}
+			log.Infof("Channel %s: output file %s", channelID, output)
+		}
+		if !c.submit {
+			continue
+		}
+		chResponse, err := resClient.SaveChannel(
+			resmgmt.SaveChannelRequest{
+				ChannelID:     channelID,
+				ChannelConfig: bytes.NewReader(channelConfigBytes),
+			},
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the consenter of %s", channelID)
+		}
+		log.Infof("Channel %s: consenter updated %s", channelID, chResponse.TransactionID)
+	}
 	return nil
 }
 
+// listChannels returns the channels the orderer participates in, using the old certificate to trust the node
+// since it may still be serving it
+func (c *renewChannelCmd) listChannels(clientSet *kubernetes.Clientset, oldTlsCert string) ([]string, error) {
+	hlfClient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return nil, err
+	}
+	ordererNode, err := helpers.GetOrdererNodeByFullName(clientSet, hlfClient, fmt.Sprintf("%s.%s", c.name, c.namespace))
+	if err != nil {
+		return nil, err
+	}
+	certPool := x509.NewCertPool()
+	certPool.AppendCertsFromPEM([]byte(oldTlsCert))
+	certPool.AppendCertsFromPEM([]byte(ordererNode.Status.TlsCert))
+	certPool.AppendCertsFromPEM([]byte(ordererNode.Status.TlsAdminCert))
+	identityBytes, err := ioutil.ReadFile(c.identity)
+	if err != nil {
+		return nil, err
+	}
+	id := &identity{}
+	err = yaml.Unmarshal(identityBytes, id)
+	if err != nil {
+		return nil, err
+	}
+	tlsClientCert, err := tls.X509KeyPair(
+		[]byte(id.Cert.Pem),
+		[]byte(id.Key.Pem),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ordererHostName, adminPort, err := helpers.GetOrdererAdminHostAndPort(clientSet, ordererNode.Spec, ordererNode.Status)
+	if err != nil {
+		return nil, err
+	}
+	osnUrl := fmt.Sprintf("https://%s:%d", ordererHostName, adminPort)
+	chResponse, err := osnadmin.ListAllChannels(osnUrl, certPool, tlsClientCert)
+	if err != nil {
+		return nil, err
+	}
+	defer chResponse.Body.Close()
+	if chResponse.StatusCode != 200 {
+		return nil, errors.Errorf("error listing channels, got status code=%d", chResponse.StatusCode)
+	}
+	channelList := &osnadmin.ChannelList{}
+	err = json.NewDecoder(chResponse.Body).Decode(channelList)
+	if err != nil {
+		return nil, err
+	}
+	var channels []string
+	for _, channel := range channelList.Channels {
+		channels = append(channels, channel.Name)
+	}
+	return channels, nil
+}
+
 func newRenewChannelCMD(io.Writer, io.Writer) *cobra.Command {
 	c := &renewChannelCmd{}
 	cmd := &cobra.Command{
@@ -60,6 +308,14 @@ func newRenewChannelCMD(io.Writer, io.Writer) *cobra.Command {
 	persistentFlags := cmd.PersistentFlags()
 	persistentFlags.StringVarP(&c.name, "name", "", "", "Orderer Service name")
 	persistentFlags.StringVarP(&c.namespace, "namespace", "", "default", "Namespace scope for this request")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK, required to update the consenters of the channels")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name for the transaction")
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization")
+	persistentFlags.StringVarP(&c.identity, "identity", "", "", "Admin identity used to list the channels of the orderer")
+	persistentFlags.StringSliceVarP(&c.channels, "channels", "", []string{}, "Channels to update, all the channels of the orderer if empty")
+	persistentFlags.StringVarP(&c.outputDir, "output-dir", "", "", "Directory to write the consenter config updates to")
+	persistentFlags.BoolVarP(&c.submit, "submit", "", false, "Submit the consenter config updates, one channel at a time")
+	persistentFlags.DurationVarP(&c.timeout, "timeout", "", 5*time.Minute, "Maximum time to wait for the renewed certificate")
 	cmd.MarkPersistentFlagRequired("name")
 	cmd.MarkPersistentFlagRequired("namespace")
 	return cmd
@@ -19,10 +19,17 @@ import (
 type InspectOptions struct {
 	Orgs       []string
 	CAs        []string
+	IdemixCAs  []string
 	OutputPath string
 }
 
 func (o InspectOptions) Validate() error {
+	for _, caNameAndMSPID := range o.IdemixCAs {
+		chunks := strings.Split(caNameAndMSPID, ";")
+		if len(chunks) != 2 || chunks[0] == "" || chunks[1] == "" {
+			return fmt.Errorf("invalid idemix CA %s, must be in format <name>.<namespace>;<MSPID>", caNameAndMSPID)
+		}
+	}
 	return nil
 }
 
@@ -60,7 +67,7 @@ Organizations:
 
     # MSPDir is the filesystem path which contains the MSP configuration.
     MSPDir: {{$org.MPSDir}}
-    MSPType: bccsp
+    MSPType: {{$org.MSPType}}
 
     # Policies defines the set of policies at this level of the config tree
     # For organization policies, their canonical path is usually
@@ -94,7 +101,8 @@ type inspectCmd struct {
 	caOpts InspectOptions
 }
 type OrganizationItem struct {
-	MPSDir string
+	MPSDir  string
+	MSPType string
 }
 
 func (c *inspectCmd) validate() error {
@@ -173,7 +181,7 @@ NodeOUs:
 			if err != nil {
 				return err
 			}
-			orgMap[mspID] = OrganizationItem{MPSDir: mspPath}
+			orgMap[mspID] = OrganizationItem{MPSDir: mspPath, MSPType: "bccsp"}
 		}
 
 	}
@@ -237,7 +245,36 @@ NodeOUs:
 		if err != nil {
 			return err
 		}
-		orgMap[peerOrg.MspID] = OrganizationItem{MPSDir: mspPath}
+		orgMap[peerOrg.MspID] = OrganizationItem{MPSDir: mspPath, MSPType: "bccsp"}
+	}
+	for _, ca := range cas {
+		for _, caNameAndNS := range c.caOpts.IdemixCAs {
+			chunks := strings.Split(caNameAndNS, ";")
+			mspID := chunks[1]
+			if !(ca.Name == chunks[0]) {
+				continue
+			}
+			caInfo, err := helpers.GetIdemixCAInfo(ca)
+			if err != nil {
+				return err
+			}
+			// the idemix MSP loader reads the keys from <MSPDir>/msp
+			orgPath := path.Join(baseOutputPath, "idemixOrganizations", mspID)
+			mspPath := path.Join(orgPath, "msp")
+			err = os.MkdirAll(mspPath, os.ModePerm)
+			if err != nil {
+				return err
+			}
+			err = ioutil.WriteFile(path.Join(mspPath, "IssuerPublicKey"), caInfo.IssuerPublicKey, os.ModePerm)
+			if err != nil {
+				return err
+			}
+			err = ioutil.WriteFile(path.Join(mspPath, "RevocationPublicKey"), caInfo.IssuerRevocationPublicKey, os.ModePerm)
+			if err != nil {
+				return err
+			}
+			orgMap[mspID] = OrganizationItem{MPSDir: orgPath, MSPType: "idemix"}
+		}
 	}
 	tmpl, err := template.New("test").Funcs(sprig.HermeticTxtFuncMap()).Parse(tmplGoConfigtx)
 	if err != nil {
@@ -256,6 +293,7 @@ NodeOUs:
 	}
 	return nil
 }
+
 func newOrgInspectCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	c := inspectCmd{out: out, errOut: errOut}
 	cmd := &cobra.Command{
@@ -271,6 +309,7 @@ func newOrgInspectCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	f := cmd.Flags()
 	f.StringSliceVarP(&c.caOpts.Orgs, "orgs", "o", []string{}, "Organizations to inspect")
 	f.StringSliceVarP(&c.caOpts.CAs, "cas", "", []string{}, `Certification authorities to add (orgs without peers) Example: --cas=ca-org1.default;Org1MSP`)
+	f.StringSliceVarP(&c.caOpts.IdemixCAs, "idemix-cas", "", []string{}, `Certification authorities to add as idemix organizations Example: --idemix-cas=ca-org1.default;Org1IdemixMSP`)
 	f.StringVarP(&c.caOpts.OutputPath, "output-path", "", "", "Output path")
 
 	return cmd
@@ -0,0 +1,188 @@
+package peer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type describePeerCmd struct {
+	name             string
+	namespace        string
+	configPath       string
+	userName         string
+	operationsScheme string
+}
+
+func (c *describePeerCmd) validate() error {
+	if c.name == "" {
+		return errors.Errorf("--name is required")
+	}
+	if c.namespace == "" {
+		return errors.Errorf("--namespace is required")
+	}
+	if (c.configPath == "") != (c.userName == "") {
+		return errors.Errorf("--config and --user must be used together")
+	}
+	return nil
+}
+
+func (c *describePeerCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	peer, err := oclient.HlfV1alpha1().FabricPeers(c.namespace).Get(context.Background(), c.name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Name:\t\t%s\n", peer.Name)
+	fmt.Printf("Namespace:\t%s\n", peer.Namespace)
+	fmt.Printf("MSP ID:\t\t%s\n", peer.Spec.MspID)
+	fmt.Printf("Image:\t\t%s:%s\n", peer.Spec.Image, peer.Spec.Tag)
+	fmt.Printf("Status:\t\t%s\n", peer.Status.Status)
+	if peer.Status.Message != "" {
+		fmt.Printf("Message:\t%s\n", peer.Status.Message)
+	}
+
+	pods, err := helpers.GetNodePods(clientSet, peer.Name, peer.Namespace)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pods:\n")
+	for _, pod := range pods {
+		fmt.Printf("  %s\tphase=%s ready=%t restarts=%d node=%s\n", pod.Name, pod.Status.Phase, helpers.IsPodReady(pod), helpers.GetPodRestarts(pod), pod.Spec.NodeName)
+		for _, volume := range helpers.GetPodVolumeUsage(clientSet, pod) {
+			if volume.Error != "" {
+				fmt.Printf("    PVC %s\terror=%s\n", volume.PVC, volume.Error)
+				continue
+			}
+			fmt.Printf("    PVC %s\trequested=%s used=%s capacity=%s\n", volume.PVC, volume.Requested, helpers.FormatBytes(volume.UsedBytes), helpers.FormatBytes(volume.CapacityBytes))
+		}
+	}
+
+	fmt.Printf("Operations:\n")
+	for _, path := range []string{"/healthz", "/version"} {
+		res, err := helpers.GetOperationsEndpoint(clientSet, c.operationsScheme, peer.Name, peer.Namespace, helpers.PeerOperationsPort, path)
+		if err != nil {
+			fmt.Printf("  %s\terror: %v\n", path, err)
+			continue
+		}
+		fmt.Printf("  %s\t%s\n", path, strings.TrimSpace(string(res)))
+	}
+
+	hostPort, err := helpers.GetPeerHostPort(clientSet, *peer)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("TLS:\n")
+	hostCheck, err := helpers.CheckCertificateHost(peer.Status.TlsCert, hostPort.Host)
+	if err != nil {
+		fmt.Printf("  error: %v\n", err)
+	} else {
+		fmt.Printf("  Public host:\t%s:%d\n", hostPort.Host, hostPort.Port)
+		fmt.Printf("  DNS SANs:\t%s\n", strings.Join(hostCheck.DNSNames, ","))
+		fmt.Printf("  IP SANs:\t%s\n", strings.Join(hostCheck.IPs, ","))
+		if hostCheck.Matches {
+			fmt.Printf("  Host match:\tOK\n")
+		} else {
+			fmt.Printf("  Host match:\tMISMATCH, the TLS certificate is not valid for %s\n", hostPort.Host)
+		}
+	}
+
+	if c.configPath == "" {
+		return nil
+	}
+	return c.printChannels(fmt.Sprintf("%s.%s", peer.Name, peer.Namespace), peer.Spec.MspID)
+}
+
+func (c *describePeerCmd) printChannels(peerName string, mspID string) error {
+	configBackend := config.FromFile(c.configPath)
+	sdk, err := fabsdk.New(configBackend)
+	if err != nil {
+		return err
+	}
+	defer sdk.Close()
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(c.userName),
+		fabsdk.WithOrg(mspID),
+	))
+	if err != nil {
+		return err
+	}
+	channelsResponse, err := resClient.QueryChannels(resmgmt.WithTargetEndpoints(peerName))
+	if err != nil {
+		return err
+	}
+	data := [][]string{}
+	for _, channel := range channelsResponse.Channels {
+		height := "-"
+		ledgerClient, err := ledger.New(sdk.ChannelContext(
+			channel.ChannelId,
+			fabsdk.WithUser(c.userName),
+			fabsdk.WithOrg(mspID),
+		))
+		if err == nil {
+			info, err := ledgerClient.QueryInfo(ledger.WithTargetEndpoints(peerName))
+			if err == nil {
+				height = fmt.Sprintf("%d", info.BCI.Height)
+			}
+		}
+		data = append(data, []string{channel.ChannelId, height})
+	}
+	fmt.Printf("Channels:\n")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Channel", "Height"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t")
+	table.SetNoWhiteSpace(true)
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
+
+func newDescribePeerCMD(io.Writer, io.Writer) *cobra.Command {
+	c := &describePeerCmd{}
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Describe a peer with live diagnostics",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.name, "name", "", "", "Peer name")
+	persistentFlags.StringVarP(&c.namespace, "namespace", "", "default", "Namespace scope for this request")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK, used to list the channels joined by the peer")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name to query the channels joined by the peer")
+	persistentFlags.StringVarP(&c.operationsScheme, "operations-scheme", "", "http", "Scheme of the operations endpoint of the peer (http/https)")
+	cmd.MarkPersistentFlagRequired("name")
+	return cmd
+}
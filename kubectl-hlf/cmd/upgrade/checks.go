@@ -0,0 +1,312 @@
+package upgrade
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-config/configtx/orderer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/osnadmin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+const pollInterval = 5 * time.Second
+
+// waitFor polls the condition until it's satisfied or the timeout expires, errors returned by the
+// condition are considered transient and only reported if the timeout is reached
+func waitFor(timeout time.Duration, description string, condition func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for {
+		done, err := condition()
+		if err == nil && done {
+			return nil
+		}
+		lastErr = err
+		if time.Now().After(deadline) {
+			if lastErr != nil {
+				return errors.Wrapf(lastErr, "timed out waiting for %s", description)
+			}
+			return errors.Errorf("timed out waiting for %s", description)
+		}
+		log.Debugf("Waiting for %s", description)
+		time.Sleep(pollInterval)
+	}
+}
+
+func waitForPods(clientSet *kubernetes.Clientset, name string, ns string, tag string, timeout time.Duration) error {
+	return waitFor(timeout, fmt.Sprintf("pods of %s.%s to be ready", name, ns), func() (bool, error) {
+		pods, err := helpers.GetNodePods(clientSet, name, ns)
+		if err != nil {
+			return false, err
+		}
+		if len(pods) == 0 {
+			return false, nil
+		}
+		for _, pod := range pods {
+			if pod.DeletionTimestamp != nil || !helpers.IsPodReady(pod) {
+				return false, nil
+			}
+			upgraded := false
+			for _, container := range pod.Spec.Containers {
+				if strings.HasSuffix(container.Image, fmt.Sprintf(":%s", tag)) {
+					upgraded = true
+				}
+			}
+			if !upgraded {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+func waitForHealth(clientSet *kubernetes.Clientset, scheme string, name string, ns string, port string, timeout time.Duration) error {
+	return waitFor(timeout, fmt.Sprintf("health checks of %s.%s to pass", name, ns), func() (bool, error) {
+		res, err := helpers.GetOperationsEndpoint(clientSet, scheme, name, ns, port, "/healthz")
+		if err != nil {
+			return false, err
+		}
+		health := &struct {
+			Status string `json:"status"`
+		}{}
+		err = json.Unmarshal(res, health)
+		if err != nil {
+			return false, err
+		}
+		return health.Status == "OK", nil
+	})
+}
+
+// waitForPeerLedger waits until the peer has caught up, on every channel it's joined to, with the
+// highest ledger height among the other peers of the organization
+func waitForPeerLedger(sdk *fabsdk.FabricSDK, mspID string, userName string, peerName string, otherPeers []string, timeout time.Duration) error {
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(userName),
+		fabsdk.WithOrg(mspID),
+	))
+	if err != nil {
+		return err
+	}
+	var channels []string
+	err = waitFor(timeout, fmt.Sprintf("peer %s to list its channels", peerName), func() (bool, error) {
+		channelsResponse, err := resClient.QueryChannels(resmgmt.WithTargetEndpoints(peerName))
+		if err != nil {
+			return false, err
+		}
+		channels = []string{}
+		for _, channel := range channelsResponse.Channels {
+			channels = append(channels, channel.ChannelId)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, channelID := range channels {
+		ledgerClient, err := ledger.New(sdk.ChannelContext(
+			channelID,
+			fabsdk.WithUser(userName),
+			fabsdk.WithOrg(mspID),
+		))
+		if err != nil {
+			return err
+		}
+		err = waitFor(timeout, fmt.Sprintf("peer %s to catch up on channel %s", peerName, channelID), func() (bool, error) {
+			info, err := ledgerClient.QueryInfo(ledger.WithTargetEndpoints(peerName))
+			if err != nil {
+				return false, err
+			}
+			var maxHeight uint64
+			for _, otherPeer := range otherPeers {
+				otherInfo, err := ledgerClient.QueryInfo(ledger.WithTargetEndpoints(otherPeer))
+				if err != nil {
+					// the peer may not be joined to this channel
+					continue
+				}
+				if otherInfo.BCI.Height > maxHeight {
+					maxHeight = otherInfo.BCI.Height
+				}
+			}
+			log.Debugf("Peer %s height=%d, highest height of the other peers=%d on channel %s", peerName, info.BCI.Height, maxHeight, channelID)
+			return info.BCI.Height >= maxHeight, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type osnAdmin struct {
+	urls          map[string]string
+	certPool      *x509.CertPool
+	tlsClientCert tls.Certificate
+	// orderers of the cluster, used to reach the consenters of the channels
+	orderers []clusterOrderer
+}
+
+type clusterOrderer struct {
+	name    string
+	url     string
+	tlsCert *x509.Certificate
+}
+
+func (o *osnAdmin) listChannels(nodeName string) ([]string, error) {
+	chResponse, err := osnadmin.ListAllChannels(o.urls[nodeName], o.certPool, o.tlsClientCert)
+	if err != nil {
+		return nil, err
+	}
+	defer chResponse.Body.Close()
+	if chResponse.StatusCode != 200 {
+		return nil, errors.Errorf("error listing channels of %s, got status code=%d", nodeName, chResponse.StatusCode)
+	}
+	channelList := &osnadmin.ChannelList{}
+	err = json.NewDecoder(chResponse.Body).Decode(channelList)
+	if err != nil {
+		return nil, err
+	}
+	var channels []string
+	for _, channel := range channelList.Channels {
+		channels = append(channels, channel.Name)
+	}
+	return channels, nil
+}
+
+func (o *osnAdmin) channelInfo(nodeName string, channelID string) (*osnadmin.ChannelInfo, error) {
+	return o.channelInfoAt(o.urls[nodeName], nodeName, channelID)
+}
+
+func (o *osnAdmin) channelInfoAt(url string, nodeName string, channelID string) (*osnadmin.ChannelInfo, error) {
+	chResponse, err := osnadmin.ListSingleChannel(url, channelID, o.certPool, o.tlsClientCert)
+	if err != nil {
+		return nil, err
+	}
+	defer chResponse.Body.Close()
+	if chResponse.StatusCode != 200 {
+		return nil, errors.Errorf("error getting channel %s of %s, got status code=%d", channelID, nodeName, chResponse.StatusCode)
+	}
+	chInfo := &osnadmin.ChannelInfo{}
+	err = json.NewDecoder(chResponse.Body).Decode(chInfo)
+	if err != nil {
+		return nil, err
+	}
+	return chInfo, nil
+}
+
+// findOrderer returns the orderer of the cluster serving with the TLS certificate of the consenter
+func (o *osnAdmin) findOrderer(consenter orderer.Consenter) *clusterOrderer {
+	if consenter.ServerTLSCert == nil {
+		return nil
+	}
+	for i, ord := range o.orderers {
+		if bytes.Equal(ord.tlsCert.Raw, consenter.ServerTLSCert.Raw) {
+			return &o.orderers[i]
+		}
+	}
+	return nil
+}
+
+// getChannelConsenters reads the consenters of the channel from its config block
+func getChannelConsenters(resClient *resmgmt.Client, channelID string) ([]orderer.Consenter, error) {
+	cfgBlock, err := helpers.GetCurrentConfigFromPeer(resClient, channelID)
+	if err != nil {
+		return nil, err
+	}
+	cftxGen := configtx.New(cfgBlock)
+	ordConfig, err := cftxGen.Orderer().Configuration()
+	if err != nil {
+		return nil, err
+	}
+	if len(ordConfig.EtcdRaft.Consenters) == 0 {
+		return nil, errors.Errorf("channel %s has no raft consenters", channelID)
+	}
+	return ordConfig.EtcdRaft.Consenters, nil
+}
+
+// waitForOrdererQuorum waits until the orderer is active and caught up on every channel, and a majority
+// of the consenters in the config of each channel are active again, consenters that can't be reached
+// count as inactive
+func waitForOrdererQuorum(sdk *fabsdk.FabricSDK, mspID string, userName string, admin *osnAdmin, nodeName string, timeout time.Duration) error {
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithUser(userName),
+		fabsdk.WithOrg(mspID),
+	))
+	if err != nil {
+		return err
+	}
+	var channels []string
+	err = waitFor(timeout, fmt.Sprintf("orderer %s to list its channels", nodeName), func() (bool, error) {
+		var err error
+		channels, err = admin.listChannels(nodeName)
+		return err == nil, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, channelID := range channels {
+		var consenters []orderer.Consenter
+		err = waitFor(timeout, fmt.Sprintf("the consenters of channel %s", channelID), func() (bool, error) {
+			var err error
+			consenters, err = getChannelConsenters(resClient, channelID)
+			return err == nil, err
+		})
+		if err != nil {
+			return err
+		}
+		err = waitFor(timeout, fmt.Sprintf("orderer %s to rejoin the cluster on channel %s", nodeName, channelID), func() (bool, error) {
+			info, err := admin.channelInfo(nodeName, channelID)
+			if err != nil {
+				return false, err
+			}
+			if info.Status != osnadmin.StatusActive {
+				return false, nil
+			}
+			maxHeight := info.Height
+			activeConsenters := 0
+			var inactive []string
+			for _, consenter := range consenters {
+				consenterName := fmt.Sprintf("%s:%d", consenter.Address.Host, consenter.Address.Port)
+				ord := admin.findOrderer(consenter)
+				if ord == nil {
+					inactive = append(inactive, consenterName)
+					continue
+				}
+				otherInfo, err := admin.channelInfoAt(ord.url, ord.name, channelID)
+				if err != nil {
+					log.Debugf("Consenter %s of channel %s can't be reached: %v", ord.name, channelID, err)
+					inactive = append(inactive, ord.name)
+					continue
+				}
+				if otherInfo.Height > maxHeight {
+					maxHeight = otherInfo.Height
+				}
+				if otherInfo.ConsensusRelation != osnadmin.ConsensusRelationConsenter || otherInfo.Status != osnadmin.StatusActive {
+					inactive = append(inactive, ord.name)
+					continue
+				}
+				activeConsenters++
+			}
+			log.Debugf(
+				"Orderer %s height=%d, highest height=%d, active consenters=%d/%d on channel %s, inactive=%v",
+				nodeName, info.Height, maxHeight, activeConsenters, len(consenters), channelID, inactive,
+			)
+			return info.Height >= maxHeight && activeConsenters > len(consenters)/2, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
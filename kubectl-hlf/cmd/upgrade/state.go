@@ -0,0 +1,75 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	nodeKindPeer    = "peer"
+	nodeKindOrderer = "orderer"
+
+	nodeStatusPending    = "pending"
+	nodeStatusUpgraded   = "upgraded"
+	nodeStatusRolledBack = "rolledback"
+	nodeStatusFailed     = "failed"
+)
+
+type upgradeNode struct {
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace"`
+	PreviousImage string `json:"previousImage"`
+	PreviousTag   string `json:"previousTag"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
+// upgradeState is persisted after every node so that a paused or failed upgrade can be resumed
+type upgradeState struct {
+	MSPID        string         `json:"mspid"`
+	PeerImage    string         `json:"peerImage"`
+	OrdererImage string         `json:"ordererImage"`
+	Version      string         `json:"version"`
+	Nodes        []*upgradeNode `json:"nodes"`
+}
+
+func readState(path string) (*upgradeState, error) {
+	stateBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &upgradeState{}
+	err = json.Unmarshal(stateBytes, state)
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *upgradeState) save(path string) error {
+	stateBytes, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, stateBytes, 0644)
+}
+
+func (s *upgradeState) pending() []*upgradeNode {
+	var nodes []*upgradeNode
+	for _, node := range s.Nodes {
+		if node.Status != nodeStatusUpgraded {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func removeState(path string) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
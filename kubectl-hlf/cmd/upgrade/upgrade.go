@@ -0,0 +1,405 @@
+package upgrade
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+type identity struct {
+	Cert Pem `json:"cert"`
+	Key  Pem `json:"key"`
+}
+type Pem struct {
+	Pem string
+}
+
+type upgradeCmd struct {
+	mspID            string
+	namespace        string
+	version          string
+	peerImage        string
+	ordererImage     string
+	configPath       string
+	userName         string
+	identity         string
+	operationsScheme string
+	timeout          time.Duration
+	stateFile        string
+	resume           bool
+	pauseAfter       int
+	noRollback       bool
+}
+
+func (c *upgradeCmd) validate() error {
+	if c.mspID == "" {
+		return errors.Errorf("--mspid is required")
+	}
+	if c.version == "" && !c.resume {
+		return errors.Errorf("--version is required")
+	}
+	if (c.configPath == "") != (c.userName == "") {
+		return errors.Errorf("--config and --user must be used together")
+	}
+	if c.identity != "" && c.configPath == "" {
+		return errors.Errorf("--identity requires --config and --user to read the consenters of the channels")
+	}
+	return nil
+}
+
+func (c *upgradeCmd) getStateFile() string {
+	if c.stateFile != "" {
+		return c.stateFile
+	}
+	return fmt.Sprintf("upgrade-%s.json", c.mspID)
+}
+
+func (c *upgradeCmd) newState(oclient *operatorv1.Clientset) (*upgradeState, error) {
+	ctx := context.Background()
+	state := &upgradeState{
+		MSPID:        c.mspID,
+		PeerImage:    c.peerImage,
+		OrdererImage: c.ordererImage,
+		Version:      c.version,
+	}
+	// orderers are upgraded first, as recommended by the Fabric upgrade guide
+	ordererNodes, err := oclient.HlfV1alpha1().FabricOrdererNodes(c.namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var orderers []*upgradeNode
+	for _, ordNode := range ordererNodes.Items {
+		if ordNode.Spec.MspID != c.mspID {
+			continue
+		}
+		orderers = append(orderers, &upgradeNode{
+			Kind:          nodeKindOrderer,
+			Name:          ordNode.Name,
+			Namespace:     ordNode.Namespace,
+			PreviousImage: ordNode.Spec.Image,
+			PreviousTag:   ordNode.Spec.Tag,
+			Status:        nodeStatusPending,
+		})
+	}
+	peers, err := oclient.HlfV1alpha1().FabricPeers(c.namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var peerNodes []*upgradeNode
+	for _, peer := range peers.Items {
+		if peer.Spec.MspID != c.mspID {
+			continue
+		}
+		peerNodes = append(peerNodes, &upgradeNode{
+			Kind:          nodeKindPeer,
+			Name:          peer.Name,
+			Namespace:     peer.Namespace,
+			PreviousImage: peer.Spec.Image,
+			PreviousTag:   peer.Spec.Tag,
+			Status:        nodeStatusPending,
+		})
+	}
+	for _, nodes := range [][]*upgradeNode{orderers, peerNodes} {
+		sort.Slice(nodes, func(i, j int) bool {
+			return fmt.Sprintf("%s.%s", nodes[i].Name, nodes[i].Namespace) < fmt.Sprintf("%s.%s", nodes[j].Name, nodes[j].Namespace)
+		})
+		state.Nodes = append(state.Nodes, nodes...)
+	}
+	if len(state.Nodes) == 0 {
+		return nil, errors.Errorf("no peers or orderer nodes found for MSP ID %s", c.mspID)
+	}
+	return state, nil
+}
+
+func (c *upgradeCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	stateFile := c.getStateFile()
+	var state *upgradeState
+	if c.resume {
+		state, err = readState(stateFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the upgrade state from %s", stateFile)
+		}
+		if state.MSPID != c.mspID {
+			return errors.Errorf("upgrade state in %s belongs to %s", stateFile, state.MSPID)
+		}
+		if c.version != "" && c.version != state.Version {
+			return errors.Errorf("upgrade state in %s is an upgrade to %s, not to %s", stateFile, state.Version, c.version)
+		}
+		if c.peerImage != "" && c.peerImage != state.PeerImage {
+			return errors.Errorf("upgrade state in %s uses the peer image %s, not %s", stateFile, state.PeerImage, c.peerImage)
+		}
+		if c.ordererImage != "" && c.ordererImage != state.OrdererImage {
+			return errors.Errorf("upgrade state in %s uses the orderer image %s, not %s", stateFile, state.OrdererImage, c.ordererImage)
+		}
+		log.Infof("Resuming upgrade of %s to %s", state.MSPID, state.Version)
+	} else {
+		// the state keeps the previous tags to roll back, recomputing them would pick the upgraded ones
+		if _, err := os.Stat(stateFile); err == nil {
+			return errors.Errorf("upgrade state %s already exists, run with --resume to continue it or remove it to start over", stateFile)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		state, err = c.newState(oclient)
+		if err != nil {
+			return err
+		}
+	}
+	err = state.save(stateFile)
+	if err != nil {
+		return err
+	}
+
+	var sdk *fabsdk.FabricSDK
+	if c.configPath != "" {
+		sdk, err = fabsdk.New(config.FromFile(c.configPath))
+		if err != nil {
+			return err
+		}
+		defer sdk.Close()
+	} else {
+		log.Warnf("--config and --user not set, the ledger height of the peers won't be checked")
+	}
+	var admin *osnAdmin
+	if c.identity != "" {
+		admin, err = c.getOSNAdmin(clientSet, state)
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Warnf("--identity not set, the raft quorum of the orderers won't be checked")
+	}
+
+	upgraded := 0
+	for _, node := range state.pending() {
+		if c.pauseAfter > 0 && upgraded >= c.pauseAfter {
+			log.Infof("Upgrade paused after %d nodes, run again with --resume to continue", upgraded)
+			return nil
+		}
+		log.Infof("Upgrading %s %s.%s from %s to %s", node.Kind, node.Name, node.Namespace, node.PreviousTag, state.Version)
+		err = c.upgradeNode(oclient, clientSet, sdk, admin, state, node)
+		if err != nil {
+			node.Status = nodeStatusFailed
+			node.Error = err.Error()
+			log.Errorf("Upgrade of %s %s.%s failed: %v", node.Kind, node.Name, node.Namespace, err)
+			if !c.noRollback {
+				rollbackErr := c.rollbackNode(oclient, clientSet, node)
+				if rollbackErr != nil {
+					log.Errorf("Rollback of %s %s.%s failed: %v", node.Kind, node.Name, node.Namespace, rollbackErr)
+				} else {
+					node.Status = nodeStatusRolledBack
+				}
+			}
+			saveErr := state.save(stateFile)
+			if saveErr != nil {
+				log.Errorf("Failed to save the upgrade state: %v", saveErr)
+			}
+			return errors.Wrapf(err, "upgrade stopped at %s %s.%s, fix the issue and run again with --resume", node.Kind, node.Name, node.Namespace)
+		}
+		node.Status = nodeStatusUpgraded
+		node.Error = ""
+		err = state.save(stateFile)
+		if err != nil {
+			return err
+		}
+		upgraded++
+		log.Infof("Upgraded %s %s.%s", node.Kind, node.Name, node.Namespace)
+	}
+	log.Infof("All nodes of %s upgraded to %s", state.MSPID, state.Version)
+	return removeState(stateFile)
+}
+
+func (c *upgradeCmd) setImage(oclient *operatorv1.Clientset, node *upgradeNode, image string, tag string) error {
+	ctx := context.Background()
+	switch node.Kind {
+	case nodeKindPeer:
+		peer, err := oclient.HlfV1alpha1().FabricPeers(node.Namespace).Get(ctx, node.Name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if image != "" {
+			peer.Spec.Image = image
+		}
+		peer.Spec.Tag = tag
+		_, err = oclient.HlfV1alpha1().FabricPeers(node.Namespace).Update(ctx, peer, v1.UpdateOptions{})
+		return err
+	case nodeKindOrderer:
+		ordNode, err := oclient.HlfV1alpha1().FabricOrdererNodes(node.Namespace).Get(ctx, node.Name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if image != "" {
+			ordNode.Spec.Image = image
+		}
+		ordNode.Spec.Tag = tag
+		_, err = oclient.HlfV1alpha1().FabricOrdererNodes(node.Namespace).Update(ctx, ordNode, v1.UpdateOptions{})
+		return err
+	}
+	return errors.Errorf("unknown node kind %s", node.Kind)
+}
+
+func (c *upgradeCmd) upgradeNode(
+	oclient *operatorv1.Clientset,
+	clientSet *kubernetes.Clientset,
+	sdk *fabsdk.FabricSDK,
+	admin *osnAdmin,
+	state *upgradeState,
+	node *upgradeNode,
+) error {
+	image := state.PeerImage
+	operationsPort := helpers.PeerOperationsPort
+	if node.Kind == nodeKindOrderer {
+		image = state.OrdererImage
+		operationsPort = helpers.OrdererOperationsPort
+	}
+	err := c.setImage(oclient, node, image, state.Version)
+	if err != nil {
+		return err
+	}
+	err = waitForPods(clientSet, node.Name, node.Namespace, state.Version, c.timeout)
+	if err != nil {
+		return err
+	}
+	err = waitForHealth(clientSet, c.operationsScheme, node.Name, node.Namespace, operationsPort, c.timeout)
+	if err != nil {
+		return err
+	}
+	fullName := fmt.Sprintf("%s.%s", node.Name, node.Namespace)
+	if node.Kind == nodeKindPeer && sdk != nil {
+		var otherPeers []string
+		for _, otherNode := range state.Nodes {
+			if otherNode.Kind == nodeKindPeer && otherNode != node {
+				otherPeers = append(otherPeers, fmt.Sprintf("%s.%s", otherNode.Name, otherNode.Namespace))
+			}
+		}
+		return waitForPeerLedger(sdk, state.MSPID, c.userName, fullName, otherPeers, c.timeout)
+	}
+	if node.Kind == nodeKindOrderer && admin != nil {
+		return waitForOrdererQuorum(sdk, state.MSPID, c.userName, admin, fullName, c.timeout)
+	}
+	return nil
+}
+
+func (c *upgradeCmd) rollbackNode(oclient *operatorv1.Clientset, clientSet *kubernetes.Clientset, node *upgradeNode) error {
+	log.Infof("Rolling back %s %s.%s to %s", node.Kind, node.Name, node.Namespace, node.PreviousTag)
+	err := c.setImage(oclient, node, node.PreviousImage, node.PreviousTag)
+	if err != nil {
+		return err
+	}
+	return waitForPods(clientSet, node.Name, node.Namespace, node.PreviousTag, c.timeout)
+}
+
+func (c *upgradeCmd) getOSNAdmin(clientSet *kubernetes.Clientset, state *upgradeState) (*osnAdmin, error) {
+	identityBytes, err := ioutil.ReadFile(c.identity)
+	if err != nil {
+		return nil, err
+	}
+	id := &identity{}
+	err = yaml.Unmarshal(identityBytes, id)
+	if err != nil {
+		return nil, err
+	}
+	tlsClientCert, err := tls.X509KeyPair(
+		[]byte(id.Cert.Pem),
+		[]byte(id.Key.Pem),
+	)
+	if err != nil {
+		return nil, err
+	}
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return nil, err
+	}
+	admin := &osnAdmin{
+		urls:          map[string]string{},
+		certPool:      x509.NewCertPool(),
+		tlsClientCert: tlsClientCert,
+	}
+	ordNodes, err := oclient.HlfV1alpha1().FabricOrdererNodes("").List(context.Background(), v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ordNode := range ordNodes.Items {
+		admin.certPool.AppendCertsFromPEM([]byte(ordNode.Status.TlsCert))
+		admin.certPool.AppendCertsFromPEM([]byte(ordNode.Status.TlsAdminCert))
+		name := fmt.Sprintf("%s.%s", ordNode.Name, ordNode.Namespace)
+		adminHost, adminPort, err := helpers.GetOrdererAdminHostAndPort(clientSet, ordNode.Spec, ordNode.Status)
+		if err != nil {
+			log.Warnf("Couldn't get the admin endpoint of orderer %s: %v", name, err)
+			continue
+		}
+		url := fmt.Sprintf("https://%s:%d", adminHost, adminPort)
+		admin.urls[name] = url
+		tlsCert, err := utils.ParseX509Certificate([]byte(ordNode.Status.TlsCert))
+		if err != nil {
+			log.Warnf("Couldn't parse the TLS certificate of orderer %s: %v", name, err)
+			continue
+		}
+		admin.orderers = append(admin.orderers, clusterOrderer{name: name, url: url, tlsCert: tlsCert})
+	}
+	for _, node := range state.Nodes {
+		if _, ok := admin.urls[fmt.Sprintf("%s.%s", node.Name, node.Namespace)]; node.Kind == nodeKindOrderer && !ok {
+			return nil, errors.Errorf("couldn't get the admin endpoint of orderer %s.%s", node.Name, node.Namespace)
+		}
+	}
+	return admin, nil
+}
+
+// NewUpgradeCmd creates the command to upgrade all the nodes of an organization one at a time
+func NewUpgradeCmd(io.Writer, io.Writer) *cobra.Command {
+	c := &upgradeCmd{}
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Rolling upgrade of all the peers and orderer nodes of an organization",
+		Long: `Upgrades the orderer nodes and then the peers of an organization one at a time, waiting for each node
+to be ready, healthy and caught up with the rest of the network before moving to the next one`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVarP(&c.mspID, "mspid", "", "", "MSP ID of the organization to upgrade")
+	persistentFlags.StringVarP(&c.namespace, "namespace", "", "", "Namespace of the nodes, all namespaces if empty")
+	persistentFlags.StringVarP(&c.version, "version", "", "", "Version to upgrade the nodes to")
+	persistentFlags.StringVarP(&c.peerImage, "peer-image", "", "", "Image of the Fabric Peer, the current image is kept if empty")
+	persistentFlags.StringVarP(&c.ordererImage, "orderer-image", "", "", "Image of the Fabric Orderer, the current image is kept if empty")
+	persistentFlags.StringVarP(&c.configPath, "config", "", "", "Configuration file for the SDK, used to check the ledger height of the peers")
+	persistentFlags.StringVarP(&c.userName, "user", "", "", "User name to check the ledger height of the peers")
+	persistentFlags.StringVarP(&c.identity, "identity", "", "", "Admin identity used to check the raft cluster through the channel participation API, requires --config and --user")
+	persistentFlags.StringVarP(&c.operationsScheme, "operations-scheme", "", "http", "Scheme of the operations endpoint of the nodes (http/https)")
+	persistentFlags.DurationVarP(&c.timeout, "timeout", "", 10*time.Minute, "Maximum time to wait for each check of a node")
+	persistentFlags.StringVarP(&c.stateFile, "state-file", "", "", "File to store the progress of the upgrade, defaults to upgrade-<mspid>.json")
+	persistentFlags.BoolVarP(&c.resume, "resume", "", false, "Resume a paused or failed upgrade from the state file")
+	persistentFlags.IntVarP(&c.pauseAfter, "pause-after", "", 0, "Pause the upgrade after upgrading this number of nodes")
+	persistentFlags.BoolVarP(&c.noRollback, "no-rollback", "", false, "Don't roll back a node to its previous version if the upgrade fails")
+	cmd.MarkPersistentFlagRequired("mspid")
+	return cmd
+}
@@ -1,11 +1,15 @@
 package utils
 
 import (
-	"github.com/pkg/errors"
-	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 	"io"
 	"io/ioutil"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/networkconfig"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 type Options struct {
@@ -25,6 +29,9 @@ func (o Options) Validate() error {
 	if o.mspID == "" {
 		return errors.New("--mspid is required")
 	}
+	if o.userName == "" {
+		return errors.New("--username is required")
+	}
 	return nil
 }
 
@@ -43,25 +50,43 @@ func (c *addUserCmd) run(args []string) error {
 	if err != nil {
 		return err
 	}
-	networkConfigMap := map[string]interface{}{}
-	err = yaml.Unmarshal(configBytes, networkConfigMap)
+	networkConfig, err := networkconfig.Unmarshal(configBytes)
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "failed to parse the network config %s", c.opts.config)
 	}
-	userMap := map[string]interface{}{}
 	userBytes, err := ioutil.ReadFile(c.opts.userPath)
 	if err != nil {
 		return err
 	}
-	err = yaml.Unmarshal(userBytes, userMap)
+	user := networkconfig.User{}
+	err = yaml.Unmarshal(userBytes, &user)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the user %s", c.opts.userPath)
+	}
+	// only the problems introduced by the new user fail the command, the file may already have others
+	previousProblems := networkConfig.Problems()
+	knownProblems := map[string]bool{}
+	for _, problem := range previousProblems {
+		knownProblems[problem] = true
+	}
+	err = networkConfig.AddUser(c.opts.mspID, c.opts.userName, user)
 	if err != nil {
 		return err
 	}
-	orgsMap := networkConfigMap["organizations"].(map[interface{}]interface{})
-	orgMap := orgsMap[c.opts.mspID].(map[interface{}]interface{})
-	users := orgMap["users"].(map[interface{}]interface{})
-	users[c.opts.userName] = userMap
-	configBytesNew, err := yaml.Marshal(networkConfigMap)
+	var newProblems []string
+	for _, problem := range networkConfig.Problems() {
+		if knownProblems[problem] {
+			continue
+		}
+		newProblems = append(newProblems, problem)
+	}
+	if len(newProblems) > 0 {
+		return errors.Errorf("invalid network config:\n  %s", strings.Join(newProblems, "\n  "))
+	}
+	for _, problem := range previousProblems {
+		log.Warnf("Network config %s: %s", c.opts.config, problem)
+	}
+	configBytesNew, err := networkConfig.Marshal()
 	if err != nil {
 		return err
 	}